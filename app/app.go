@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cheersmas/jou/app/constants"
@@ -15,6 +17,7 @@ import (
 	"github.com/cheersmas/jou/app/navigation"
 	"github.com/cheersmas/jou/app/styles"
 	"github.com/cheersmas/jou/app/views"
+	"github.com/cheersmas/jou/config"
 	"github.com/cheersmas/jou/ports"
 )
 
@@ -25,8 +28,11 @@ type App struct {
 	views        map[constants.View]views.View
 }
 
-func NewApp(ctx context.Context, service ports.JournalService) *App {
+func NewApp(ctx context.Context, service ports.JournalService, cfg config.Config) *App {
 	state := navigation.NewAppState(ctx, service)
+	state.LockAfter = cfg.LockAfter
+	state.LockPIN = cfg.LockPIN
+	state.LastActivity = time.Now()
 	router := navigation.NewRouter(state)
 	inputHandler := input.NewInputHandler(state, router)
 
@@ -40,29 +46,89 @@ func NewApp(ctx context.Context, service ports.JournalService) *App {
 	items := []list.Item{}
 	li := list.New(items, list.NewDefaultDelegate(), 0, 0)
 
+	li.DisableQuitKeybindings()
+
+	// "/" opens full-text search, so the list's own filter moves to "f",
+	// and "d" deletes rather than paging
+	li.KeyMap.Filter.SetKeys("f")
+	li.KeyMap.Filter.SetHelp("f", "filter")
+	li.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
 	li.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{
+		bindings := []key.Binding{
 			key.NewBinding(
 				key.WithKeys("backspace"),
 				key.WithHelp("backspace", "back to menu"),
 			),
 		}
+		if state.CurrentView == constants.TrashView {
+			return append(bindings,
+				key.NewBinding(
+					key.WithKeys("r"),
+					key.WithHelp("r", "restore"),
+				),
+				key.NewBinding(
+					key.WithKeys("x"),
+					key.WithHelp("x", "purge"),
+				),
+			)
+		}
+		return append(bindings,
+			key.NewBinding(
+				key.WithKeys("d"),
+				key.WithHelp("d", "delete"),
+			),
+			key.NewBinding(
+				key.WithKeys("/"),
+				key.WithHelp("/", "search"),
+			),
+			key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "tag filter"),
+			),
+		)
 	}
 
-	li.DisableQuitKeybindings()
+	// The revision list owns j/k, so the diff only scrolls by page
+	rv := viewport.New(0, 0)
+	rv.KeyMap = viewport.KeyMap{
+		PageDown: key.NewBinding(key.WithKeys("pgdown")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup")),
+	}
+
+	si := textinput.New()
+	si.Prompt = "/ "
+	si.Placeholder = "Search journals..."
 
 	state.Textarea = ti
 	state.Viewport = vp
 	state.List = li
+	state.SearchInput = si
+	state.PromptInput = textinput.New()
+	state.PassphraseInput = textinput.New()
+	state.PassphraseInput.EchoMode = textinput.EchoPassword
+	if state.CurrentView == constants.UnlockView {
+		state.PassphraseInput.Focus()
+	} else {
+		router.RecoverDrafts()
+	}
+	state.RevisionViewport = rv
 
 	// Initialize views
 	viewMap := map[constants.View]views.View{
-		constants.MenuView:    views.MenuView{},
-		constants.AddView:     views.AddView{},
-		constants.EditView:    views.ListView{},
-		constants.ListView:    views.ListView{},
-		constants.JournalView: views.JournalView{},
-		constants.ConfirmView: views.ConfirmView{},
+		constants.MenuView:      views.MenuView{},
+		constants.AddView:       views.AddView{},
+		constants.EditView:      views.ListView{},
+		constants.ListView:      views.ListView{},
+		constants.JournalView:   views.JournalView{},
+		constants.ConfirmView:   views.ConfirmView{},
+		constants.PromptView:    views.PromptView{},
+		constants.SearchView:    views.SearchView{},
+		constants.RevisionsView: views.RevisionsView{},
+		constants.TrashView:     views.ListView{},
+		constants.TagsView:      views.TagsView{},
+		constants.CalendarView:  views.CalendarView{},
+		constants.UnlockView:    views.UnlockView{},
+		constants.RecoverView:   views.RecoverView{},
 	}
 
 	return &App{
@@ -74,7 +140,7 @@ func NewApp(ctx context.Context, service ports.JournalService) *App {
 }
 
 func (a App) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, a.router.IdleTick(), a.router.Autosave())
 }
 
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -83,10 +149,23 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		a.state.LastActivity = time.Now()
+		previousView := a.state.CurrentView
 		cmd = a.inputHandler.HandleKeyMsg(msg)
 		cmds = append(cmds, cmd)
+		// A key that switched views was meant for the view it left, so don't
+		// let the new one type it into a textarea or search box.
+		if a.state.CurrentView != previousView {
+			return a, tea.Batch(cmds...)
+		}
 	case tea.WindowSizeMsg:
 		a.handleWindowSize(msg)
+	case navigation.EditorFinishedMsg:
+		cmds = append(cmds, a.router.HandleEditorFinished(msg))
+	case navigation.IdleTickMsg:
+		return a, a.router.IdleTick()
+	case navigation.AutosaveTickMsg:
+		return a, a.router.Autosave()
 	case error:
 		a.state.LastError = msg
 		return a, nil
@@ -117,6 +196,9 @@ func (a *App) handleWindowSize(msg tea.WindowSizeMsg) {
 	a.state.Textarea.SetHeight(msg.Height - 10) // Adjust as needed
 	a.state.Textarea.SetWidth(msg.Width)
 
+	a.state.RevisionViewport.Width = msg.Width - views.RevisionsColumnWidth - 6
+	a.state.RevisionViewport.Height = msg.Height - 10
+
 	if !a.state.Ready {
 		a.state.Viewport = viewport.New(msg.Width, msg.Height-10)
 		a.state.Ready = true
@@ -126,8 +208,8 @@ func (a *App) handleWindowSize(msg tea.WindowSizeMsg) {
 	}
 }
 
-func Root(ctx context.Context, js ports.JournalService) {
-	app := NewApp(ctx, js)
+func Root(ctx context.Context, js ports.JournalService, cfg config.Config) {
+	app := NewApp(ctx, js, cfg)
 	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
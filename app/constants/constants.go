@@ -1,16 +1,37 @@
 package constants
 
+import "time"
+
 type View string
 
 const (
-	MenuView    View = "Menu"
-	AddView     View = "Add"
-	ListView    View = "View"
-	JournalView View = "Journal"
-	EditView    View = "Edit"
-	ConfirmView View = "Confirm"
+	MenuView      View = "Menu"
+	AddView       View = "Add"
+	ListView      View = "View"
+	JournalView   View = "Journal"
+	EditView      View = "Edit"
+	ConfirmView   View = "Confirm"
+	PromptView    View = "Prompt"
+	SearchView    View = "Search"
+	RevisionsView View = "History"
+	TrashView     View = "Trash"
+	TagsView      View = "Tags"
+	CalendarView  View = "Calendar"
+	UnlockView    View = "Unlock"
+	RecoverView   View = "Recover"
+
+	TimeFormat       = "2 Jan, 2006"
+	MonthFormat      = "January 2006"
+	EditedTimeFormat = "2 Jan, 2006 15:04"
+	Gap              = "\n\n"
+	UnsavedId        = -1
+	UnmarkedRevision = -1
 
-	TimeFormat = "2 Jan, 2006"
-	Gap        = "\n\n"
-	UnsavedId  = -1
+	SearchDebounce = 250 * time.Millisecond
+	// AutosaveInterval is how often the entry being written is kept as a
+	// draft.
+	AutosaveInterval = 5 * time.Second
+	// StaleDraftAge is how long a draft goes unsaved before it counts as
+	// left behind. Until then it may belong to another jou still running.
+	StaleDraftAge = 3 * AutosaveInterval
 )
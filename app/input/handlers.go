@@ -1,12 +1,9 @@
 package input
 
 import (
-	"log"
-
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cheersmas/jou/app/constants"
 	"github.com/cheersmas/jou/app/navigation"
-	"github.com/cheersmas/jou/domains"
 )
 
 type InputHandler struct {
@@ -22,29 +19,33 @@ func NewInputHandler(state *navigation.AppState, router *navigation.Router) *Inp
 }
 
 func (h *InputHandler) HandleKeyMsg(msg tea.KeyMsg) tea.Cmd {
+	if h.state.PickingDate {
+		return h.handleDatePickerKey(msg)
+	}
+
 	switch msg.String() {
 	case "esc":
 		return h.handleEscapeKey()
 	case "ctrl+c":
 		return h.handleQuitKey(msg)
 	case "up", "k":
-		// Only move cursor for menu view, let list component handle its own navigation
-		if h.state.CurrentView == constants.MenuView {
-			h.state.MoveCursor(-1)
-		}
+		h.handleCursorKey(msg, -1)
 	case "down", "j":
-		// Only move cursor for menu view, let list component handle its own navigation
-		if h.state.CurrentView == constants.MenuView {
-			h.state.MoveCursor(1)
-		}
+		h.handleCursorKey(msg, 1)
+	case "/":
+		return h.handleSearchKey()
 	case "enter":
 		return h.handleEnterKey()
 	case "ctrl+s":
 		return h.handleSaveKey()
+	case "ctrl+o":
+		return h.handleEditorKey()
+	case "ctrl+g":
+		return h.handleDateKey()
 	case "backspace":
 		return h.handleBackspaceKey()
 	default:
-		return h.handleDefaultKey()
+		return h.handleDefaultKey(msg)
 	}
 	return nil
 }
@@ -52,35 +53,80 @@ func (h *InputHandler) HandleKeyMsg(msg tea.KeyMsg) tea.Cmd {
 func (h *InputHandler) handleEscapeKey() tea.Cmd {
 	switch h.state.CurrentView {
 	case constants.AddView:
-		h.state.Textarea.Blur()
-		return nil
+		if h.state.Textarea.Focused() {
+			h.state.Textarea.Blur()
+			return nil
+		}
+		return h.router.LeaveEditor()
 	case constants.ConfirmView:
-		h.state.CurrentView = constants.AddView
+		h.router.CancelConfirmation()
+		return nil
+	case constants.PromptView:
+		h.router.CancelPrompt()
+		return nil
+	case constants.SearchView:
+		h.state.SearchInput.Blur()
+		h.router.Back()
+		return nil
+	case constants.UnlockView:
+		return tea.Quit
+	case constants.RecoverView:
+		h.router.SkipDrafts()
 		return nil
 	}
 	return nil
 }
 
-func (h *InputHandler) handleQuitKey(msg tea.KeyMsg) tea.Cmd {
+func (h *InputHandler) handleCursorKey(msg tea.KeyMsg, direction int) {
 	switch h.state.CurrentView {
-	case constants.AddView:
-		h.state.CurrentView = constants.ConfirmView
-		return nil
-	default:
-		return tea.Quit
+	case constants.MenuView:
+		// Only move cursor for menu view, let list component handle its own navigation
+		h.state.MoveCursor(direction)
+	case constants.SearchView:
+		// j and k are typed into the search box, only the arrows move the cursor
+		if msg.Type == tea.KeyUp || msg.Type == tea.KeyDown {
+			h.state.MoveCursor(direction)
+		}
+	case constants.RevisionsView, constants.TagsView, constants.CalendarView, constants.RecoverView:
+		h.state.MoveCursor(direction)
+	}
+}
+
+func (h *InputHandler) handleSearchKey() tea.Cmd {
+	switch h.state.CurrentView {
+	case constants.ListView, constants.EditView:
+		if h.state.List.SettingFilter() {
+			return nil
+		}
+		return h.router.OpenSearch()
 	}
+	return nil
+}
+
+func (h *InputHandler) handleQuitKey(msg tea.KeyMsg) tea.Cmd {
+	return h.router.Quit()
 }
 
 func (h *InputHandler) handleEnterKey() tea.Cmd {
 	switch h.state.CurrentView {
 	case constants.MenuView:
 		return h.router.HandleMenuSelection()
-	case constants.ListView, constants.EditView:
+	case constants.ListView, constants.EditView, constants.TrashView:
 		return h.router.HandleJournalSelection()
+	case constants.SearchView:
+		return h.router.HandleSearchSelection()
+	case constants.TagsView:
+		return h.router.HandleTagSelection()
+	case constants.CalendarView:
+		return h.router.HandleCalendarSelection()
 	case constants.ConfirmView:
-		h.state.ResetCursorPosition()
-		h.state.CurrentView = constants.MenuView
-		return nil
+		return h.router.AcceptConfirmation()
+	case constants.PromptView:
+		return h.router.SubmitPrompt()
+	case constants.UnlockView:
+		return h.router.Unlock()
+	case constants.RecoverView:
+		return h.router.RestoreDraft()
 	}
 	return nil
 }
@@ -89,51 +135,142 @@ func (h *InputHandler) handleSaveKey() tea.Cmd {
 	if h.state.CurrentView != constants.AddView {
 		return nil
 	}
+	return h.router.SaveEntry()
+}
 
-	content := h.state.Textarea.Value()
-	if content == "" {
+func (h *InputHandler) handleEditorKey() tea.Cmd {
+	if h.state.CurrentView != constants.AddView {
 		return nil
 	}
+	return h.router.OpenEditor()
+}
 
-	var err error
-	if h.state.RecentlySavedId == constants.UnsavedId {
-		journal := domains.Journal{Content: content}
-		h.state.RecentlySavedId, err = h.state.Service.Create(h.state.Ctx, journal)
-	} else {
-		val, err := h.state.Service.Update(h.state.Ctx, h.state.RecentlySavedId, content)
-		if err != nil {
-			h.state.LastError = err
-			log.Printf("Save error: %v", err)
-		}
-		h.state.RecentlySavedId = val
+func (h *InputHandler) handleDateKey() tea.Cmd {
+	if h.state.CurrentView != constants.AddView {
+		return nil
 	}
+	return h.router.OpenDatePicker()
+}
 
-	editingJournal, err := h.state.Service.Read(h.state.Ctx, h.state.RecentlySavedId)
-	h.state.EditingJournal = &editingJournal
-
-	if err != nil {
-		h.state.LastError = err
-		log.Printf("Save error: %v", err)
+// handleDatePickerKey moves the date of the entry being written: days with
+// left and right, weeks with up and down, months with [ and ].
+func (h *InputHandler) handleDatePickerKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "left", "h":
+		h.router.ShiftEntryDate(0, -1)
+	case "right", "l":
+		h.router.ShiftEntryDate(0, 1)
+	case "up", "k":
+		h.router.ShiftEntryDate(0, -7)
+	case "down", "j":
+		h.router.ShiftEntryDate(0, 7)
+	case "[", "pgup":
+		h.router.ShiftEntryDate(-1, 0)
+	case "]", "pgdown":
+		h.router.ShiftEntryDate(1, 0)
+	case "t":
+		h.router.ResetEntryDate()
+	case "enter", "esc", "ctrl+g":
+		return h.router.CloseDatePicker()
+	case "ctrl+c":
+		return tea.Batch(h.router.CloseDatePicker(), h.handleQuitKey(msg))
 	}
 	return nil
 }
 
 func (h *InputHandler) handleBackspaceKey() tea.Cmd {
 	switch h.state.CurrentView {
-	case constants.ListView, constants.EditView:
-		h.state.CurrentView = constants.MenuView
-		h.state.CursorPosition = 0
-	case constants.JournalView:
-		h.state.CurrentView = constants.ListView
+	case constants.ListView, constants.EditView, constants.TrashView:
+		h.router.LeaveList()
+	case constants.JournalView, constants.RevisionsView, constants.TagsView, constants.CalendarView:
+		h.router.Back()
 	case constants.ConfirmView:
-		h.state.CurrentView = constants.AddView
+		h.router.CancelConfirmation()
+	case constants.RecoverView:
+		h.router.SkipDrafts()
 	}
 	return nil
 }
 
-func (h *InputHandler) handleDefaultKey() tea.Cmd {
-	if h.state.CurrentView == constants.AddView && !h.state.Textarea.Focused() {
-		return h.state.Textarea.Focus()
+func (h *InputHandler) handleDefaultKey(msg tea.KeyMsg) tea.Cmd {
+	switch h.state.CurrentView {
+	case constants.AddView:
+		if !h.state.Textarea.Focused() {
+			return h.state.Textarea.Focus()
+		}
+	case constants.ConfirmView:
+		if msg.String() == "d" {
+			return h.router.DiscardConfirmation()
+		}
+	case constants.ListView, constants.EditView:
+		if h.state.List.SettingFilter() {
+			return nil
+		}
+		switch msg.String() {
+		case "d":
+			return h.router.ConfirmDelete()
+		case "t":
+			return h.router.FilterByTag()
+		}
+	case constants.JournalView:
+		switch msg.String() {
+		case "h":
+			return h.router.OpenRevisions()
+		case "d":
+			return h.router.ConfirmDelete()
+		case "e":
+			return h.router.OpenEditor()
+		case "t":
+			return h.router.EditTags()
+		case "n":
+			return h.router.EditTitle()
+		}
+	case constants.TrashView:
+		if h.state.List.SettingFilter() {
+			return nil
+		}
+		switch msg.String() {
+		case "r":
+			return h.router.RestoreJournal()
+		case "x":
+			return h.router.PurgeJournal()
+		}
+	case constants.CalendarView:
+		switch msg.String() {
+		case "left", "h":
+			h.router.ShiftCalendar(0, -1)
+		case "right", "l":
+			h.router.ShiftCalendar(0, 1)
+		case "[", "pgup":
+			h.router.ShiftCalendar(-1, 0)
+		case "]", "pgdown":
+			h.router.ShiftCalendar(1, 0)
+		case "t":
+			h.router.CalendarToday()
+		}
+	case constants.TagsView:
+		switch msg.String() {
+		case "r":
+			return h.router.RenameTag(false)
+		case "m":
+			return h.router.RenameTag(true)
+		}
+	case constants.RecoverView:
+		switch msg.String() {
+		case "r":
+			return h.router.RestoreDraft()
+		case "s":
+			return h.router.PublishDraft()
+		case "x":
+			return h.router.ConfirmDiscardDraft()
+		}
+	case constants.RevisionsView:
+		switch msg.String() {
+		case " ":
+			h.router.ToggleRevisionBase()
+		case "r":
+			return h.router.RestoreRevision()
+		}
 	}
 	return nil
 }
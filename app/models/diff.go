@@ -0,0 +1,112 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffInsert
+	DiffDelete
+)
+
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// LineDiff compares two texts line by line using their longest common
+// subsequence, returning the lines needed to turn from into to. The
+// subsequence is found with Hirschberg's algorithm, which keeps memory in
+// proportion to the number of lines rather than their product.
+func LineDiff(from, to string) []DiffLine {
+	return diffLines(nil, splitLines(from), splitLines(to))
+}
+
+// diffLines appends the lines that turn a into b to lines.
+func diffLines(lines []DiffLine, a, b []string) []DiffLine {
+	// lines shared at either end are equal without searching
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	lines = appendLines(lines, DiffEqual, a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		lines = appendLines(lines, DiffInsert, b)
+	case len(b) == 0:
+		lines = appendLines(lines, DiffDelete, a)
+	case len(a) == 1:
+		if k := slices.Index(b, a[0]); k >= 0 {
+			lines = appendLines(lines, DiffInsert, b[:k])
+			lines = appendLines(lines, DiffEqual, a)
+			lines = appendLines(lines, DiffInsert, b[k+1:])
+		} else {
+			lines = appendLines(lines, DiffDelete, a)
+			lines = appendLines(lines, DiffInsert, b)
+		}
+	default:
+		// Split a in half and b where the halves' subsequences add up to the
+		// longest, then diff both parts on their own.
+		mid := len(a) / 2
+		forward := lcsLengths(a[:mid], b)
+		backward := lcsLengths(reversed(a[mid:]), reversed(b))
+		split, best := 0, -1
+		for j := 0; j <= len(b); j++ {
+			if n := forward[j] + backward[len(b)-j]; n > best {
+				split, best = j, n
+			}
+		}
+		lines = diffLines(lines, a[:mid], b[:split])
+		lines = diffLines(lines, a[mid:], b[split:])
+	}
+	return appendLines(lines, DiffEqual, common)
+}
+
+// lcsLengths returns, for every j, the length of the longest common
+// subsequence of a and b[:j], one row at a time.
+func lcsLengths(a, b []string) []int {
+	prev, row := make([]int, len(b)+1), make([]int, len(b)+1)
+	for _, line := range a {
+		for j := range b {
+			if line == b[j] {
+				row[j+1] = prev[j] + 1
+			} else {
+				row[j+1] = max(prev[j+1], row[j])
+			}
+		}
+		prev, row = row, prev
+	}
+	return prev
+}
+
+func reversed(lines []string) []string {
+	r := slices.Clone(lines)
+	slices.Reverse(r)
+	return r
+}
+
+func appendLines(lines []DiffLine, op DiffOp, texts []string) []DiffLine {
+	for _, text := range texts {
+		lines = append(lines, DiffLine{Op: op, Text: text})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
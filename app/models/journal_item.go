@@ -1,21 +1,61 @@
 package models
 
 import (
+	"strings"
+
+	"github.com/cheersmas/jou/app/constants"
 	"github.com/cheersmas/jou/domains"
 )
 
 type JournalItem struct {
-	title string
-	desc  string
+	journal domains.Journal
+	title   string
+	desc    string
 }
 
+// NewJournalItem titles the item with the journal's title, or its first
+// heading or line, and describes it with the date and tags.
 func NewJournalItem(journal domains.Journal) JournalItem {
+	title := journal.DisplayTitle()
+	if title == "" {
+		title = "Untitled"
+	}
+	if journal.Starred {
+		title = "★ " + title
+	}
+
+	desc := journal.EntryDate.Format(constants.TimeFormat)
+	if journal.UpdatedAt != nil {
+		desc += " · edited " + journal.UpdatedAt.Format(constants.TimeFormat)
+	}
+	if journal.DeletedAt != nil {
+		desc += " · deleted " + journal.DeletedAt.Format(constants.TimeFormat)
+	}
+	if len(journal.Tags) > 0 {
+		desc += " · " + Hashtags(journal.Tags)
+	}
+
 	return JournalItem{
-		title: journal.CreatedAt.Format("2 Jan, 2006"),
-		desc:  journal.Content,
+		journal: journal,
+		title:   title,
+		desc:    desc,
+	}
+}
+
+// Hashtags formats tags the way they are written in entries.
+func Hashtags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
 	}
+	return "#" + strings.Join(tags, " #")
 }
 
-func (i JournalItem) Title() string       { return i.title }
-func (i JournalItem) Description() string { return i.desc }
-func (i JournalItem) FilterValue() string { return i.desc }
+func (i JournalItem) Journal() domains.Journal { return i.journal }
+func (i JournalItem) Title() string            { return i.title }
+func (i JournalItem) Description() string      { return i.desc }
+
+// FilterValue lets the list filter match an entry by its title, tags or
+// anything written in it.
+func (i JournalItem) FilterValue() string {
+	return i.journal.DisplayTitle() + " " + Hashtags(i.journal.Tags) + " " + i.journal.Content
+}
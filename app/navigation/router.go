@@ -1,16 +1,48 @@
 package navigation
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cheersmas/jou/app/constants"
 	"github.com/cheersmas/jou/app/models"
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/editor"
 )
 
+// EditorFinishedMsg reports that the external editor started by OpenEditor
+// has exited.
+type EditorFinishedMsg struct {
+	view     constants.View
+	path     string
+	original string
+	err      error
+}
+
+// DatePickerClosedMsg hands the keyboard back to the textarea once the date
+// picker is done with it.
+type DatePickerClosedMsg struct{}
+
+// IdleTickMsg asks the router to check whether the journal has been idle
+// long enough to lock it.
+type IdleTickMsg struct{}
+
+// AutosaveTickMsg asks the router to keep the entry being written as a
+// draft.
+type AutosaveTickMsg struct{}
+
+// ErrWrongPIN is shown when the PIN typed in UnlockView does not match
+// lock_pin.
+var ErrWrongPIN = errors.New("wrong PIN")
+
 type Router struct {
 	state *AppState
 }
@@ -20,11 +52,51 @@ func NewRouter(state *AppState) *Router {
 }
 
 func (r *Router) LoadJournals() error {
+	if !r.state.DayFilter.IsZero() {
+		journals, err := r.state.Service.ListAll(r.state.Ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch journals: %w", err)
+		}
+		var onDay []domains.Journal
+		for _, journal := range journals {
+			if DayKey(journal.EntryDate) == DayKey(r.state.DayFilter) {
+				onDay = append(onDay, journal)
+			}
+		}
+		r.setJournals("Journals · "+r.state.DayFilter.Format(constants.TimeFormat), onDay)
+		return nil
+	}
+
+	if r.state.TagFilter != "" {
+		journals, err := r.state.Service.ListByTag(r.state.Ctx, r.state.TagFilter)
+		if err != nil {
+			return fmt.Errorf("failed to fetch journals tagged #%s: %w", r.state.TagFilter, err)
+		}
+		r.setJournals("Journals #"+r.state.TagFilter, journals)
+		return nil
+	}
+
 	journals, err := r.state.Service.ListAll(r.state.Ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch journals: %w", err)
 	}
 
+	r.setJournals("Journals", journals)
+	return nil
+}
+
+// LoadTrash fills the list with deleted journals instead.
+func (r *Router) LoadTrash() error {
+	journals, err := r.state.Service.ListDeleted(r.state.Ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch trash: %w", err)
+	}
+
+	r.setJournals("Trash", journals)
+	return nil
+}
+
+func (r *Router) setJournals(title string, journals []domains.Journal) {
 	r.state.Journals = journals
 	var items []list.Item
 	for _, journal := range journals {
@@ -32,17 +104,53 @@ func (r *Router) LoadJournals() error {
 	}
 
 	r.state.List.SetItems(items)
-	r.state.List.Title = "Journals"
-	return nil
+	r.state.List.Title = title
+}
+
+// selectedJournal is the journal highlighted in the list, taking any active
+// filter into account.
+func (r *Router) selectedJournal() (domains.Journal, bool) {
+	item, ok := r.state.List.SelectedItem().(models.JournalItem)
+	if !ok {
+		return domains.Journal{}, false
+	}
+	return item.Journal(), true
+}
+
+// Navigate switches to view, remembering the current one so Back can return
+// to it.
+func (r *Router) Navigate(view constants.View) {
+	r.state.History = append(r.state.History, r.state.CurrentView)
+	r.state.CurrentView = view
+}
+
+// Back returns to the view Navigate was last called from, or to the menu when
+// there is nothing to go back to.
+func (r *Router) Back() {
+	n := len(r.state.History)
+	if n == 0 {
+		r.state.CurrentView = constants.MenuView
+		return
+	}
+	r.state.CurrentView = r.state.History[n-1]
+	r.state.History = r.state.History[:n-1]
 }
 
 func (r *Router) HandleMenuSelection() tea.Cmd {
 	selectedView := r.state.Options[r.state.CursorPosition]
 	r.state.CurrentView = selectedView
+	r.state.History = nil
+	r.state.TagFilter = ""
+	r.state.DayFilter = time.Time{}
 	r.state.ResetCursorPosition()
 
 	if selectedView == constants.AddView {
-		r.state.Textarea.Focus()
+		r.state.CurrentView = constants.MenuView
+		return r.startEntry(time.Time{})
+	}
+
+	if selectedView == constants.SearchView {
+		return r.state.SearchInput.Focus()
 	}
 
 	if selectedView == constants.ListView || selectedView == constants.EditView {
@@ -51,39 +159,1194 @@ func (r *Router) HandleMenuSelection() tea.Cmd {
 			log.Printf("Error loading journals: %v", err)
 		}
 	}
+
+	if selectedView == constants.TagsView {
+		r.state.TagCursor = 0
+		r.reloadTags()
+	}
+
+	if selectedView == constants.CalendarView {
+		r.state.CalendarDay = time.Now()
+		r.reloadCalendar()
+	}
+
+	if selectedView == constants.TrashView {
+		if err := r.LoadTrash(); err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading trash: %v", err)
+		}
+	}
 	return nil
 }
 
 func (r *Router) HandleJournalSelection() tea.Cmd {
-	// Get the selected index from the list component instead of state cursor position
-	selectedIndex := r.state.List.Index()
+	selected, ok := r.selectedJournal()
+	if !ok {
+		return nil
+	}
+
+	r.state.ResetCursorPosition()
+	if r.state.CurrentView == constants.EditView {
+		return r.editJournal(selected)
+	}
+
+	r.state.ViewingJournal = &selected
+	r.state.Viewport.SetContent(selected.Content)
+	r.state.Viewport.GotoTop()
+	r.Navigate(constants.JournalView)
+	return nil
+}
+
+// editJournal opens journal in AddView.
+func (r *Router) editJournal(journal domains.Journal) tea.Cmd {
+	return r.guardEditor("switch entries", func() tea.Cmd {
+		r.state.EditingJournal = &journal
+		r.state.RecentlySavedId = journal.Id
+		r.state.EntryDate = journal.EntryDate
+		r.state.LastError = nil
+		r.state.Textarea.SetValue(strings.TrimSpace(journal.Content))
+		r.Navigate(constants.AddView)
+		return r.state.Textarea.Focus()
+	})
+}
 
-	if selectedIndex >= len(r.state.Journals) {
+// SaveEntry creates or updates the journal being written in AddView.
+func (r *Router) SaveEntry() tea.Cmd {
+	if err := r.saveEntry(); err != nil {
+		r.state.LastError = err
+		log.Printf("Save error: %v", err)
+	}
+	return nil
+}
+
+func (r *Router) saveEntry() error {
+	content := r.state.Textarea.Value()
+	if content == "" {
 		return nil
 	}
 
-	selected := r.state.Journals[selectedIndex]
+	if r.state.RecentlySavedId == constants.UnsavedId {
+		journal := domains.Journal{Content: content, EntryDate: r.state.EntryDate}
+		id, err := r.state.Service.Create(r.state.Ctx, journal)
+		if err != nil {
+			return err
+		}
+		r.state.RecentlySavedId = id
+	} else {
+		if _, err := r.state.Service.Update(r.state.Ctx, r.state.RecentlySavedId, content); err != nil {
+			return err
+		}
+		if r.entryDateChanged() {
+			if _, err := r.state.Service.SetEntryDate(r.state.Ctx, r.state.RecentlySavedId, r.state.EntryDate); err != nil {
+				return err
+			}
+		}
+	}
 
-	if r.state.CurrentView == constants.EditView {
-		r.state.EditingJournal = &selected
-		r.state.CurrentView = constants.AddView
-		r.state.RecentlySavedId = selected.Id
-		content := strings.TrimSpace(selected.Content)
+	editingJournal, err := r.state.Service.Read(r.state.Ctx, r.state.RecentlySavedId)
+	if err != nil {
+		return err
+	}
+	r.state.EditingJournal = &editingJournal
+	r.state.EntryDate = editingJournal.EntryDate
+	r.state.Dirty = false
+	return r.discardDraft()
+}
+
+// OpenDatePicker lets the arrow keys move the date of the entry being
+// written instead of the textarea cursor.
+func (r *Router) OpenDatePicker() tea.Cmd {
+	r.state.EntryDate = r.state.CurrentEntryDate()
+	r.state.PickingDate = true
+	r.state.Textarea.Blur()
+	return nil
+}
+
+func (r *Router) CloseDatePicker() tea.Cmd {
+	r.state.PickingDate = false
+	return func() tea.Msg { return DatePickerClosedMsg{} }
+}
+
+// ShiftEntryDate moves the entry being written by whole days or months,
+// keeping its time of day. Entries can be backdated, saving one dated in the
+// future fails.
+func (r *Router) ShiftEntryDate(months, days int) {
+	r.setEntryDate(r.state.CurrentEntryDate().AddDate(0, months, days))
+}
+
+// ResetEntryDate puts the entry being written back on today. A time later
+// today is moved back to now when it is saved.
+func (r *Router) ResetEntryDate() {
+	date, now := r.state.CurrentEntryDate(), time.Now()
+	r.setEntryDate(time.Date(now.Year(), now.Month(), now.Day(), date.Hour(), date.Minute(), date.Second(), 0, now.Location()))
+}
+
+func (r *Router) setEntryDate(date time.Time) {
+	if !date.Equal(r.state.CurrentEntryDate()) {
+		r.state.Dirty = true
+	}
+	r.state.EntryDate = date
+}
+
+func (r *Router) entryDateChanged() bool {
+	return r.state.EditingJournal != nil && !r.state.EntryDate.IsZero() &&
+		!r.state.EntryDate.Equal(r.state.EditingJournal.EntryDate)
+}
+
+// OpenEditor suspends the program and opens the entry being written or read
+// in $VISUAL or $EDITOR. An encrypted journal stays in jou's own editor.
+func (r *Router) OpenEditor() tea.Cmd {
+	var content string
+	switch r.state.CurrentView {
+	case constants.AddView:
+		content = r.state.Textarea.Value()
+	case constants.JournalView:
+		if r.state.ViewingJournal == nil {
+			return nil
+		}
+		content = r.state.ViewingJournal.Content
+	default:
+		return nil
+	}
+	if r.state.Service.Encrypted() {
+		r.state.LastError = editor.ErrEncrypted
+		return nil
+	}
+
+	path, err := editor.TempFile(content)
+	if err != nil {
+		r.state.LastError = err
+		log.Printf("Editor error: %v", err)
+		return nil
+	}
+	cmd, err := editor.Command(path)
+	if err != nil {
+		os.Remove(path)
+		r.state.LastError = err
+		log.Printf("Editor error: %v", err)
+		return nil
+	}
+
+	view := r.state.CurrentView
+	r.state.EditorOpen = true
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return EditorFinishedMsg{view: view, path: path, original: content, err: err}
+	})
+}
+
+// HandleEditorFinished saves what came back from the editor, but only when
+// the content actually changed.
+func (r *Router) HandleEditorFinished(msg EditorFinishedMsg) tea.Cmd {
+	r.state.EditorOpen = false
+	r.state.LastActivity = time.Now()
+	content, err := editor.ReadBack(msg.path)
+	if msg.err != nil {
+		err = msg.err
+	}
+	if err != nil {
+		r.state.LastError = err
+		log.Printf("Editor error: %v", err)
+		return nil
+	}
+	if content == strings.TrimRight(msg.original, "\n") {
+		return nil
+	}
+
+	switch msg.view {
+	case constants.AddView:
 		r.state.Textarea.SetValue(content)
-	} else {
+		r.state.Dirty = true
+		return r.SaveEntry()
+	case constants.JournalView:
+		id := r.state.ViewingJournal.Id
+		if _, err := r.state.Service.Update(r.state.Ctx, id, content); err != nil {
+			r.state.LastError = err
+			log.Printf("Save error: %v", err)
+			return nil
+		}
+		journal, err := r.state.Service.Read(r.state.Ctx, id)
+		if err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading journal: %v", err)
+			return nil
+		}
+		r.state.ViewingJournal = &journal
+		r.state.Viewport.SetContent(journal.Content)
+		if err := r.LoadJournals(); err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading journals: %v", err)
+		}
+	}
+	return nil
+}
+
+// Confirm asks the user to confirm c before running it. Cancelling returns
+// to the current view.
+func (r *Router) Confirm(c Confirmation) tea.Cmd {
+	r.state.Confirmation = &c
+	r.Navigate(constants.ConfirmView)
+	return nil
+}
+
+// AcceptConfirmation leaves ConfirmView and runs the confirmed action from
+// the view that asked for it.
+func (r *Router) AcceptConfirmation() tea.Cmd {
+	c := r.state.Confirmation
+	r.CancelConfirmation()
+	if c == nil || c.OnConfirm == nil {
+		return nil
+	}
+	return c.OnConfirm()
+}
+
+// DiscardConfirmation leaves ConfirmView and runs its discard choice, if it
+// has one.
+func (r *Router) DiscardConfirmation() tea.Cmd {
+	c := r.state.Confirmation
+	if c == nil || c.OnDiscard == nil {
+		return nil
+	}
+	r.CancelConfirmation()
+	return c.OnDiscard()
+}
+
+func (r *Router) CancelConfirmation() {
+	r.state.Confirmation = nil
+	r.Back()
+}
+
+// Ask shows a prompt for a line of text, starting out with value.
+func (r *Router) Ask(p Prompt, value string) tea.Cmd {
+	r.state.Prompt = &p
+	r.state.PromptInput.SetValue(value)
+	r.state.PromptInput.CursorEnd()
+	r.Navigate(constants.PromptView)
+	return r.state.PromptInput.Focus()
+}
+
+// SubmitPrompt passes the answer to the prompt's OnSubmit and returns to the
+// view that asked, unless OnSubmit rejected it.
+func (r *Router) SubmitPrompt() tea.Cmd {
+	p := r.state.Prompt
+	if p == nil {
+		r.Back()
+		return nil
+	}
+	if p.OnSubmit != nil {
+		if err := p.OnSubmit(strings.TrimSpace(r.state.PromptInput.Value())); err != nil {
+			p.Err = err
+			return nil
+		}
+	}
+	r.CancelPrompt()
+	return nil
+}
+
+func (r *Router) CancelPrompt() {
+	r.state.Prompt = nil
+	r.state.PromptInput.Blur()
+	r.Back()
+}
+
+// Unlock checks the passphrase, or the PIN of an unencrypted journal, typed
+// in UnlockView and continues to the menu, or back to the entry that was
+// being written when the journal was locked. A wrong passphrase keeps
+// UnlockView open.
+func (r *Router) Unlock() tea.Cmd {
+	passphrase := r.state.PassphraseInput.Value()
+	r.state.PassphraseInput.Reset()
+	if r.state.Service.Encrypted() {
+		if err := r.state.Service.Unlock(r.state.Ctx, passphrase); err != nil {
+			r.state.LastError = err
+			return nil
+		}
+	} else if subtle.ConstantTimeCompare([]byte(passphrase), []byte(r.state.LockPIN)) != 1 {
+		r.state.LastError = ErrWrongPIN
+		return nil
+	}
+
+	r.state.LastError = nil
+	r.state.LastActivity = time.Now()
+	r.state.PassphraseInput.Blur()
+	r.state.History = nil
+	r.state.CurrentView = constants.MenuView
+
+	switch r.state.LockedView {
+	case "", constants.RecoverView:
+		r.RecoverDrafts()
+	case constants.AddView:
+		r.state.LockedView = ""
+		return r.resumeEntry()
+	}
+	r.state.LockedView = ""
+	return nil
+}
+
+// LockEnabled reports whether the journal locks itself after
+// AppState.LockAfter without a key press. Unencrypted journals need a PIN to
+// unlock them again.
+func (r *Router) LockEnabled() bool {
+	return r.state.LockAfter > 0 && (r.state.Service.Encrypted() || r.state.LockPIN != "")
+}
+
+// IdleTick locks the journal once it has gone LockAfter without a key
+// press, and schedules the next check for when that could next happen.
+func (r *Router) IdleTick() tea.Cmd {
+	if !r.LockEnabled() {
+		return nil
+	}
+
+	wait := r.state.LockAfter
+	switch {
+	case r.state.CurrentView == constants.UnlockView, r.state.EditorOpen:
+		// Nothing to hide, or $EDITOR has the terminal and the lock would
+		// not be seen.
+	case time.Since(r.state.LastActivity) >= r.state.LockAfter:
+		r.Lock()
+	default:
+		wait -= time.Since(r.state.LastActivity)
+	}
+	return tea.Tick(wait, func(time.Time) tea.Msg { return IdleTickMsg{} })
+}
+
+// Lock keeps the entry being written as a draft, takes every journal off
+// the screen and asks for the PIN or passphrase in UnlockView. Encrypted
+// journals also forget their key. The journal stays open if the draft can't
+// be saved, so nothing written is lost.
+func (r *Router) Lock() {
+	writing := r.writing()
+	if err := r.saveDraft(); err != nil {
+		r.state.LastError = err
+		log.Printf("Failed to save draft before locking: %v", err)
+		return
+	}
+
+	switch {
+	case writing:
+		r.state.LockedView = constants.AddView
+	case r.state.CurrentView == constants.RecoverView:
+		r.state.LockedView = constants.RecoverView
+	default:
+		r.state.LockedView = constants.MenuView
+	}
+
+	r.state.ViewingJournal = nil
+	r.state.EditingJournal = nil
+	r.state.Viewport.SetContent("")
+	r.state.Textarea.Reset()
+	r.state.Textarea.Blur()
+	r.state.Journals = nil
+	r.state.List.SetItems(nil)
+	r.state.List.ResetFilter()
+	r.state.SearchInput.Reset()
+	r.state.SearchInput.Blur()
+	r.state.SearchResults = nil
+	r.state.Revisions = nil
+	r.state.RevisionViewport.SetContent("")
+	r.state.CalendarEntries = nil
+	r.state.Tags = nil
+	r.state.Drafts = nil
+	r.state.Prompt = nil
+	r.state.PromptInput.Reset()
+	r.state.PromptInput.Blur()
+	r.state.Confirmation = nil
+	r.state.PickingDate = false
+	r.state.LastError = nil
+
+	if r.state.Service.Encrypted() {
+		r.state.Service.Lock()
+	}
+	r.state.History = nil
+	r.state.CurrentView = constants.UnlockView
+	r.state.PassphraseInput.Focus()
+}
+
+// resumeEntry reopens the entry that was being written when Lock was
+// called, with the text of its draft.
+func (r *Router) resumeEntry() tea.Cmd {
+	r.state.EditingJournal = nil
+	content := ""
+	if r.state.RecentlySavedId != constants.UnsavedId {
+		journal, err := r.state.Service.Read(r.state.Ctx, r.state.RecentlySavedId)
+		if err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading journal: %v", err)
+			return nil
+		}
+		r.state.EditingJournal = &journal
+		content = strings.TrimSpace(journal.Content)
+	}
+	if r.state.Draft.Id != 0 {
+		drafts, err := r.state.Service.ListDrafts(r.state.Ctx)
+		if err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading draft: %v", err)
+			return nil
+		}
+		for _, draft := range drafts {
+			if draft.Id == r.state.Draft.Id {
+				content = draft.Content
+			}
+		}
+	}
+
+	r.state.Textarea.SetValue(content)
+	r.Navigate(constants.AddView)
+	return r.state.Textarea.Focus()
+}
+
+// Autosave keeps the entry being written as a draft every
+// constants.AutosaveInterval, so it survives jou or the terminal dying
+// before it is saved.
+func (r *Router) Autosave() tea.Cmd {
+	if err := r.saveDraft(); err != nil {
+		log.Printf("Autosave error: %v", err)
+	}
+	return tea.Tick(constants.AutosaveInterval, func(time.Time) tea.Msg { return AutosaveTickMsg{} })
+}
+
+// writing reports whether AddView is open, possibly under a dialog.
+func (r *Router) writing() bool {
+	return r.state.CurrentView == constants.AddView || slices.Contains(r.state.History, constants.AddView)
+}
+
+// saveDraft writes the entry being written to its draft when it changed
+// since the last autosave, and drops the draft once there is nothing
+// unsaved left. A draft that did not change, or can't be read back while
+// $EDITOR is open or the journal is locked, is touched instead, so other
+// sessions see it is still being written.
+func (r *Router) saveDraft() error {
+	if r.state.EditorOpen || r.state.CurrentView == constants.UnlockView {
+		return r.touchDraft()
+	}
+	if !r.writing() {
+		return nil
+	}
+	if !r.HasUnsavedChanges() {
+		return r.discardDraft()
+	}
+
+	draft := domains.Draft{Id: r.state.Draft.Id, Content: r.state.Textarea.Value(), EntryDate: r.state.EntryDate}
+	if r.state.RecentlySavedId != constants.UnsavedId {
+		draft.JournalId = r.state.RecentlySavedId
+	}
+	if draft.Id != 0 && draft.Content == r.state.Draft.Content && draft.EntryDate.Equal(r.state.Draft.EntryDate) {
+		return r.touchDraft()
+	}
+
+	id, err := r.state.Service.SaveDraft(r.state.Ctx, draft)
+	if err != nil {
+		return err
+	}
+	draft.Id = id
+	r.state.Draft = draft
+	return nil
+}
+
+func (r *Router) touchDraft() error {
+	if r.state.Draft.Id == 0 {
+		return nil
+	}
+	return r.state.Service.TouchDraft(r.state.Ctx, r.state.Draft.Id)
+}
+
+func (r *Router) discardDraft() error {
+	if r.state.Draft.Id == 0 {
+		return nil
+	}
+	err := r.state.Service.DiscardDraft(r.state.Ctx, r.state.Draft.Id)
+	if errors.Is(err, domains.ErrDraftNotFound) {
+		err = nil
+	}
+	if err == nil {
+		r.state.Draft = domains.Draft{}
+	}
+	return err
+}
+
+// RecoverDrafts offers the drafts an earlier session left behind in
+// RecoverView, if there are any. Drafts saved within constants.StaleDraftAge
+// are skipped, since a jou running alongside may still be writing them.
+func (r *Router) RecoverDrafts() {
+	all, err := r.state.Service.ListDrafts(r.state.Ctx)
+	if err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading drafts: %v", err)
+		return
+	}
+	var drafts []domains.Draft
+	for _, draft := range all {
+		if time.Since(draft.UpdatedAt) >= constants.StaleDraftAge {
+			drafts = append(drafts, draft)
+		}
+	}
+	r.state.Drafts = drafts
+	r.state.DraftCursor = min(r.state.DraftCursor, max(0, len(drafts)-1))
+	if len(drafts) == 0 {
+		if r.state.CurrentView == constants.RecoverView {
+			r.state.History = nil
+			r.state.CurrentView = constants.MenuView
+		}
+		return
+	}
+	r.state.CurrentView = constants.RecoverView
+}
+
+func (r *Router) selectedDraft() (domains.Draft, bool) {
+	if r.state.DraftCursor >= len(r.state.Drafts) {
+		return domains.Draft{}, false
+	}
+	return r.state.Drafts[r.state.DraftCursor], true
+}
+
+// RestoreDraft opens the highlighted draft in AddView. It stays a draft
+// until it is saved.
+func (r *Router) RestoreDraft() tea.Cmd {
+	draft, ok := r.selectedDraft()
+	if !ok {
+		return nil
+	}
+
+	r.state.EditingJournal = nil
+	r.state.RecentlySavedId = constants.UnsavedId
+	if draft.JournalId != 0 {
+		journal, err := r.state.Service.Read(r.state.Ctx, draft.JournalId)
+		if err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading journal: %v", err)
+			return nil
+		}
+		r.state.EditingJournal = &journal
+		r.state.RecentlySavedId = journal.Id
+	}
+	r.state.Draft = draft
+	r.state.EntryDate = draft.EntryDate
+	r.state.Dirty = true
+	r.state.LastError = nil
+	r.state.Textarea.SetValue(draft.Content)
+
+	r.state.History = nil
+	r.state.CurrentView = constants.MenuView
+	r.Navigate(constants.AddView)
+	return r.state.Textarea.Focus()
+}
+
+// PublishDraft saves the highlighted draft as its journal without opening
+// it.
+func (r *Router) PublishDraft() tea.Cmd {
+	draft, ok := r.selectedDraft()
+	if !ok {
+		return nil
+	}
+	if _, err := r.state.Service.PublishDraft(r.state.Ctx, draft); err != nil {
+		r.state.LastError = err
+		log.Printf("Save error: %v", err)
+		return nil
+	}
+	r.state.LastError = nil
+	r.RecoverDrafts()
+	return nil
+}
+
+// ConfirmDiscardDraft asks before throwing the highlighted draft away.
+func (r *Router) ConfirmDiscardDraft() tea.Cmd {
+	draft, ok := r.selectedDraft()
+	if !ok {
+		return nil
+	}
+	return r.Confirm(Confirmation{
+		Title:        "Discard Draft",
+		Prompt:       fmt.Sprintf("Throw away the draft from %s? It can't be brought back.", draft.UpdatedAt.Format(constants.EditedTimeFormat)),
+		ConfirmLabel: "discard",
+		OnConfirm: func() tea.Cmd {
+			if err := r.state.Service.DiscardDraft(r.state.Ctx, draft.Id); err != nil {
+				r.state.LastError = err
+				log.Printf("Failed to discard draft: %v", err)
+				return nil
+			}
+			r.RecoverDrafts()
+			return nil
+		},
+	})
+}
+
+// SkipDrafts leaves the drafts for the next time jou starts.
+func (r *Router) SkipDrafts() {
+	r.state.Drafts = nil
+	r.state.LastError = nil
+	r.state.History = nil
+	r.state.CurrentView = constants.MenuView
+}
+
+// EditTags asks for the explicit tags of the journal being read. Tags
+// written in its text are not listed since they can only be changed there.
+func (r *Router) EditTags() tea.Cmd {
+	if r.state.ViewingJournal == nil {
+		return nil
+	}
+	journal := *r.state.ViewingJournal
+
+	return r.Ask(Prompt{
+		Title:  "Tags",
+		Prompt: "Tags for this entry, separated by spaces. #tags in the text are kept anyway.",
+		OnSubmit: func(value string) error {
+			if _, err := r.state.Service.SetTags(r.state.Ctx, journal.Id, strings.Fields(value)); err != nil {
+				return err
+			}
+			updated, err := r.state.Service.Read(r.state.Ctx, journal.Id)
+			if err != nil {
+				return err
+			}
+			r.state.ViewingJournal = &updated
+			return r.LoadJournals()
+		},
+	}, strings.Join(journal.ExtraTags(), " "))
+}
+
+// EditTitle asks for the title of the journal being read. Leaving it empty
+// goes back to the first heading or line of the text.
+func (r *Router) EditTitle() tea.Cmd {
+	if r.state.ViewingJournal == nil {
+		return nil
+	}
+	journal := *r.state.ViewingJournal
+
+	return r.Ask(Prompt{
+		Title:  "Title",
+		Prompt: "Title for this entry. Leave empty to use its first heading or line.",
+		OnSubmit: func(value string) error {
+			if _, err := r.state.Service.SetTitle(r.state.Ctx, journal.Id, value); err != nil {
+				return err
+			}
+			updated, err := r.state.Service.Read(r.state.Ctx, journal.Id)
+			if err != nil {
+				return err
+			}
+			r.state.ViewingJournal = &updated
+			return r.LoadJournals()
+		},
+	}, journal.Title)
+}
+
+// FilterByTag asks which tag the list should be limited to. An empty answer
+// shows every journal again.
+func (r *Router) FilterByTag() tea.Cmd {
+	return r.Ask(Prompt{
+		Title:  "Filter by tag",
+		Prompt: "Only show entries with this tag, or leave empty to show all.",
+		OnSubmit: func(value string) error {
+			tag := ""
+			if value != "" {
+				var err error
+				if tag, err = domains.NormalizeTag(value); err != nil {
+					return err
+				}
+			}
+			r.state.TagFilter = tag
+			return r.LoadJournals()
+		},
+	}, r.state.TagFilter)
+}
+
+func (r *Router) LoadTags() error {
+	tags, err := r.state.Service.ListTags(r.state.Ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	r.state.Tags = tags
+	r.state.TagCursor = min(r.state.TagCursor, max(0, len(tags)-1))
+	return nil
+}
+
+func (r *Router) reloadTags() {
+	if err := r.LoadTags(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading tags: %v", err)
+	}
+}
+
+// HandleTagSelection lists the journals carrying the highlighted tag.
+func (r *Router) HandleTagSelection() tea.Cmd {
+	if r.state.TagCursor >= len(r.state.Tags) {
+		return nil
+	}
+
+	r.state.TagFilter = r.state.Tags[r.state.TagCursor].Name
+	if err := r.LoadJournals(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading journals: %v", err)
+		return nil
+	}
+	r.Navigate(constants.ListView)
+	return nil
+}
+
+// LeaveList goes back from the journal list to the tag browser when it was
+// opened from there, and to the menu otherwise.
+func (r *Router) LeaveList() {
+	r.Back()
+	r.state.ResetCursorPosition()
+	switch r.state.CurrentView {
+	case constants.TagsView:
+		r.state.TagFilter = ""
+		r.reloadTags()
+	case constants.CalendarView:
+		r.state.DayFilter = time.Time{}
+		r.reloadCalendar()
+	}
+}
+
+// LoadCalendar groups every journal by the day it is dated.
+func (r *Router) LoadCalendar() error {
+	journals, err := r.state.Service.ListAll(r.state.Ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch journals: %w", err)
+	}
+
+	entries := map[string][]domains.Journal{}
+	for _, journal := range journals {
+		day := DayKey(journal.EntryDate)
+		entries[day] = append(entries[day], journal)
+	}
+	r.state.CalendarEntries = entries
+	return nil
+}
+
+func (r *Router) reloadCalendar() {
+	if err := r.LoadCalendar(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading calendar: %v", err)
+	}
+}
+
+// ShiftCalendar moves the highlighted day. Moving by months keeps the day of
+// the month where possible, so 31 Jan goes to 28 or 29 Feb.
+func (r *Router) ShiftCalendar(months, days int) {
+	day := r.state.CalendarDay
+	if months != 0 {
+		first := time.Date(day.Year(), day.Month()+time.Month(months), 1, 0, 0, 0, 0, time.Local)
+		last := first.AddDate(0, 1, -1).Day()
+		day = time.Date(first.Year(), first.Month(), min(day.Day(), last), 0, 0, 0, 0, time.Local)
+	}
+	r.state.CalendarDay = day.AddDate(0, 0, days)
+}
+
+func (r *Router) CalendarToday() {
+	r.state.CalendarDay = time.Now()
+}
+
+// HandleCalendarSelection opens the highlighted day: its entry, a list when
+// it has several, or a new entry dated that day when it has none.
+func (r *Router) HandleCalendarSelection() tea.Cmd {
+	day := r.state.CalendarDay
+	entries := r.state.EntriesOn(day)
+
+	switch len(entries) {
+	case 0:
+		now := time.Now()
+		return r.startEntry(time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local))
+	case 1:
+		selected := entries[0]
 		r.state.ViewingJournal = &selected
 		r.state.Viewport.SetContent(selected.Content)
-		r.state.CurrentView = constants.JournalView
+		r.state.Viewport.GotoTop()
+		r.Navigate(constants.JournalView)
+	default:
+		r.state.DayFilter = day
+		if err := r.LoadJournals(); err != nil {
+			r.state.LastError = err
+			log.Printf("Error loading journals: %v", err)
+			return nil
+		}
+		r.Navigate(constants.ListView)
 	}
+	return nil
+}
 
-	r.state.ResetCursorPosition()
+// startEntry opens a blank entry in AddView dated date.
+func (r *Router) startEntry(date time.Time) tea.Cmd {
+	return r.guardEditor("switch entries", func() tea.Cmd {
+		r.state.EntryDate = date
+		r.state.LastError = nil
+		r.Navigate(constants.AddView)
+		return r.state.Textarea.Focus()
+	})
+}
+
+// RenameTag asks for a new name for the highlighted tag. With merge set the
+// name must be an existing tag, which the highlighted one is folded into.
+func (r *Router) RenameTag(merge bool) tea.Cmd {
+	if r.state.TagCursor >= len(r.state.Tags) {
+		return nil
+	}
+	tag := r.state.Tags[r.state.TagCursor]
+
+	p := Prompt{
+		Title:  "Rename #" + tag.Name,
+		Prompt: fmt.Sprintf("New name for #%s. Every entry using it changes, including #%s written in the text.", tag.Name, tag.Name),
+	}
+	if merge {
+		p.Title = "Merge #" + tag.Name
+		p.Prompt = fmt.Sprintf("Existing tag to merge #%s into. Entries tagged #%s get that tag instead.", tag.Name, tag.Name)
+	}
+	p.OnSubmit = func(value string) error {
+		rename := r.state.Service.RenameTag
+		if merge {
+			rename = r.state.Service.MergeTags
+		}
+		if _, err := rename(r.state.Ctx, tag.Name, value); err != nil {
+			if errors.Is(err, domains.ErrTagExists) {
+				return fmt.Errorf("%w, press m to merge into it", err)
+			}
+			return err
+		}
+
+		r.reloadTags()
+		// Keep the cursor on the tag that now holds the entries.
+		if name, err := domains.NormalizeTag(value); err == nil {
+			for i, t := range r.state.Tags {
+				if t.Name == name {
+					r.state.TagCursor = i
+				}
+			}
+		}
+		return nil
+	}
+
+	value := tag.Name
+	if merge {
+		value = ""
+	}
+	return r.Ask(p, value)
+}
+
+// guardEditor runs next once the entry being written has been dealt with.
+// Unsaved changes are not thrown away silently: the user is asked to save
+// or discard them before doing what, or to stay in the editor. Every way
+// out of AddView goes through here.
+func (r *Router) guardEditor(what string, next func() tea.Cmd) tea.Cmd {
+	leave := func() tea.Cmd {
+		r.closeEditor()
+		return next()
+	}
+	if !r.writing() || !r.HasUnsavedChanges() {
+		return leave()
+	}
+
+	return r.Confirm(Confirmation{
+		Title:        "Unsaved Changes",
+		Prompt:       "This entry has changes that were not saved.",
+		ConfirmLabel: "save and " + what,
+		OnConfirm: func() tea.Cmd {
+			// A failed save stays in the editor with the error shown
+			if err := r.saveEntry(); err != nil {
+				r.state.LastError = err
+				log.Printf("Save error: %v", err)
+				return nil
+			}
+			return leave()
+		},
+		DiscardLabel: "discard and " + what,
+		OnDiscard: func() tea.Cmd {
+			if err := r.discardDraft(); err != nil {
+				log.Printf("Failed to discard draft: %v", err)
+			}
+			return leave()
+		},
+	})
+}
+
+// closeEditor forgets the entry in AddView, leaving a blank one behind. The
+// history is popped back to the view AddView was opened from.
+func (r *Router) closeEditor() {
+	if i := slices.Index(r.state.History, constants.AddView); i >= 0 {
+		r.state.CurrentView = constants.AddView
+		r.state.History = r.state.History[:i+1]
+	}
+	if r.state.CurrentView == constants.AddView {
+		r.Back()
+	}
+
+	r.state.EditingJournal = nil
+	r.state.RecentlySavedId = constants.UnsavedId
+	r.state.EntryDate = time.Time{}
+	r.state.PickingDate = false
+	r.state.Draft = domains.Draft{}
+	r.state.Dirty = false
+	r.state.Textarea.Reset()
+	r.state.Textarea.Blur()
+}
+
+// LeaveEditor returns from AddView to the view it was opened from.
+func (r *Router) LeaveEditor() tea.Cmd {
+	return r.guardEditor("go back", func() tea.Cmd {
+		switch r.state.CurrentView {
+		case constants.ListView, constants.EditView:
+			if err := r.LoadJournals(); err != nil {
+				r.state.LastError = err
+				log.Printf("Error loading journals: %v", err)
+			}
+		case constants.CalendarView:
+			r.reloadCalendar()
+		}
+		return nil
+	})
+}
+
+// Quit exits jou, asking about unsaved changes first.
+func (r *Router) Quit() tea.Cmd {
+	if r.state.CurrentView == constants.ConfirmView && r.writing() {
+		// Asking about unsaved changes already, quitting becomes one of
+		// the answers.
+		r.CancelConfirmation()
+	}
+	return r.guardEditor("quit", func() tea.Cmd { return tea.Quit })
+}
+
+// ConfirmDelete asks before moving the highlighted journal, or the one being
+// read, to the trash.
+func (r *Router) ConfirmDelete() tea.Cmd {
+	var selected domains.Journal
+	if r.state.CurrentView == constants.JournalView {
+		if r.state.ViewingJournal == nil {
+			return nil
+		}
+		selected = *r.state.ViewingJournal
+	} else {
+		var ok bool
+		if selected, ok = r.selectedJournal(); !ok {
+			return nil
+		}
+	}
+
+	return r.Confirm(Confirmation{
+		Title:        "Delete Entry",
+		Prompt:       fmt.Sprintf("Move the entry from %s to the trash?", selected.EntryDate.Format(constants.TimeFormat)),
+		ConfirmLabel: "delete",
+		OnConfirm: func() tea.Cmd {
+			return r.deleteJournal(selected.Id)
+		},
+	})
+}
+
+func (r *Router) deleteJournal(id int) tea.Cmd {
+	if _, err := r.state.Service.Delete(r.state.Ctx, id); err != nil {
+		r.state.LastError = err
+		log.Printf("Error deleting journal: %v", err)
+		return nil
+	}
+
+	if r.state.CurrentView == constants.JournalView {
+		r.state.ViewingJournal = nil
+		r.Back()
+		if r.state.CurrentView == constants.CalendarView {
+			r.reloadCalendar()
+		}
+	}
+
+	// Drop the entry from search results too in case we came from there.
+	results := r.state.SearchResults[:0]
+	for _, result := range r.state.SearchResults {
+		if result.Journal.Id != id {
+			results = append(results, result)
+		}
+	}
+	r.state.SearchResults = results
+	r.state.SearchCursor = 0
+
+	if err := r.LoadJournals(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading journals: %v", err)
+	}
+	return nil
+}
+
+// RestoreJournal takes the highlighted journal out of the trash.
+func (r *Router) RestoreJournal() tea.Cmd {
+	selected, ok := r.selectedJournal()
+	if !ok {
+		return nil
+	}
+
+	if _, err := r.state.Service.Restore(r.state.Ctx, selected.Id); err != nil {
+		r.state.LastError = err
+		log.Printf("Error restoring journal: %v", err)
+		return nil
+	}
+	return r.reloadTrash()
+}
+
+// PurgeJournal permanently deletes the highlighted journal from the trash
+// once confirmed.
+func (r *Router) PurgeJournal() tea.Cmd {
+	selected, ok := r.selectedJournal()
+	if !ok {
+		return nil
+	}
+
+	return r.Confirm(Confirmation{
+		Title:        "Purge Entry",
+		Prompt:       fmt.Sprintf("Permanently delete the entry from %s? This cannot be undone.", selected.EntryDate.Format(constants.TimeFormat)),
+		ConfirmLabel: "delete forever",
+		OnConfirm: func() tea.Cmd {
+			return r.purgeJournal(selected.Id)
+		},
+	})
+}
+
+func (r *Router) purgeJournal(id int) tea.Cmd {
+	if _, err := r.state.Service.Purge(r.state.Ctx, id); err != nil {
+		r.state.LastError = err
+		log.Printf("Error purging journal: %v", err)
+		return nil
+	}
+	return r.reloadTrash()
+}
+
+func (r *Router) reloadTrash() tea.Cmd {
+	if err := r.LoadTrash(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading trash: %v", err)
+	}
+	return nil
+}
+
+// OpenSearch switches to the search view from wherever the user is.
+func (r *Router) OpenSearch() tea.Cmd {
+	r.Navigate(constants.SearchView)
+	return r.state.SearchInput.Focus()
+}
+
+// HandleSearchSelection opens the highlighted search result, scrolled to the
+// first line containing a match.
+func (r *Router) HandleSearchSelection() tea.Cmd {
+	if r.state.SearchCursor >= len(r.state.SearchResults) {
+		return nil
+	}
+
+	result := r.state.SearchResults[r.state.SearchCursor]
+	selected, err := r.state.Service.Read(r.state.Ctx, result.Journal.Id)
+	if err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading journal: %v", err)
+		return nil
+	}
+
+	r.state.ViewingJournal = &selected
+	r.state.Viewport.SetContent(selected.Content)
+	r.state.Viewport.SetYOffset(firstHitLine(selected.Content, result.Snippet))
+	r.Navigate(constants.JournalView)
 	return nil
 }
 
+// OpenRevisions shows the history of the journal being viewed.
+func (r *Router) OpenRevisions() tea.Cmd {
+	if r.state.ViewingJournal == nil {
+		return nil
+	}
+	if err := r.loadRevisions(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading revisions: %v", err)
+		return nil
+	}
+	r.Navigate(constants.RevisionsView)
+	return nil
+}
+
+// ToggleRevisionBase marks the highlighted revision as the one every other
+// revision is compared against, or clears the mark.
+func (r *Router) ToggleRevisionBase() {
+	if r.state.RevisionBase == r.state.RevisionCursor {
+		r.state.RevisionBase = constants.UnmarkedRevision
+	} else {
+		r.state.RevisionBase = r.state.RevisionCursor
+	}
+	r.state.RevisionViewport.GotoTop()
+}
+
+// RestoreRevision makes the highlighted revision the journal's content.
+func (r *Router) RestoreRevision() tea.Cmd {
+	revision := r.state.Revisions[r.state.RevisionCursor]
+	if r.state.RevisionCursor == 0 {
+		// already the current content
+		return nil
+	}
+
+	if _, err := r.state.Service.RestoreRevision(r.state.Ctx, revision.JournalId, revision.Id); err != nil {
+		r.state.LastError = err
+		log.Printf("Error restoring revision: %v", err)
+		return nil
+	}
+
+	journal, err := r.state.Service.Read(r.state.Ctx, revision.JournalId)
+	if err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading journal: %v", err)
+		return nil
+	}
+	r.state.ViewingJournal = &journal
+	r.state.Viewport.SetContent(journal.Content)
+
+	if err := r.loadRevisions(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading revisions: %v", err)
+	}
+	if err := r.LoadJournals(); err != nil {
+		r.state.LastError = err
+		log.Printf("Error loading journals: %v", err)
+	}
+	return nil
+}
+
+func (r *Router) loadRevisions() error {
+	journal := r.state.ViewingJournal
+	revisions, err := r.state.Service.ListRevisions(r.state.Ctx, journal.Id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch revisions: %w", err)
+	}
+
+	current := domains.Revision{JournalId: journal.Id, Content: journal.Content, CreatedAt: journal.CreatedAt}
+	if journal.UpdatedAt != nil {
+		current.CreatedAt = *journal.UpdatedAt
+	}
+
+	r.state.Revisions = append([]domains.Revision{current}, revisions...)
+	r.state.RevisionCursor = 0
+	r.state.RevisionBase = constants.UnmarkedRevision
+	r.state.RevisionViewport.GotoTop()
+	return nil
+}
+
+// firstHitLine finds the line of content holding the first term highlighted
+// in snippet.
+func firstHitLine(content, snippet string) int {
+	_, hit, ok := strings.Cut(snippet, domains.HighlightStart)
+	if !ok {
+		return 0
+	}
+	hit, _, _ = strings.Cut(hit, domains.HighlightEnd)
+	// A highlighted phrase may wrap onto the next line, so look for its first word.
+	fields := strings.Fields(strings.ToLower(hit))
+	if len(fields) == 0 {
+		return 0
+	}
+	hit = fields[0]
+
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(line), hit) {
+			return i
+		}
+	}
+	return 0
+}
+
+// HasUnsavedChanges reports whether the entry in AddView was changed since
+// it was opened or last saved. A new entry with no text has nothing to
+// save.
 func (r *Router) HasUnsavedChanges() bool {
-	if r.state.RecentlySavedId == constants.UnsavedId {
-		return true
+	if r.state.RecentlySavedId == constants.UnsavedId && r.state.Textarea.Value() == "" {
+		return false
 	}
-	return r.state.RecentlySavedId != constants.UnsavedId &&
-		r.state.Textarea.Value() != r.state.EditingJournal.Content
+	return r.state.Dirty
 }
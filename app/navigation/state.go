@@ -2,15 +2,40 @@ package navigation
 
 import (
 	"context"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cheersmas/jou/app/constants"
 	"github.com/cheersmas/jou/domains"
 	"github.com/cheersmas/jou/ports"
 )
 
+// Prompt asks for a line of text in PromptView and hands it to OnSubmit. An
+// error from OnSubmit keeps the prompt open and is shown below the input.
+type Prompt struct {
+	Title    string
+	Prompt   string
+	OnSubmit func(value string) error
+	Err      error
+}
+
+// Confirmation is the question ConfirmView asks before running OnConfirm.
+type Confirmation struct {
+	Title  string
+	Prompt string
+	// ConfirmLabel describes what pressing enter does
+	ConfirmLabel string
+	OnConfirm    func() tea.Cmd
+	// DiscardLabel and OnDiscard offer a third choice on "d", for
+	// questions about unsaved changes.
+	DiscardLabel string
+	OnDiscard    func() tea.Cmd
+}
+
 type AppState struct {
 	// Core dependencies
 	Ctx     context.Context
@@ -20,12 +45,69 @@ type AppState struct {
 	CurrentView    constants.View
 	Options        []constants.View
 	CursorPosition int
+	History        []constants.View
 
 	// Journal data
 	Journals       []domains.Journal
 	List           list.Model
 	ViewingJournal *domains.Journal
 	EditingJournal *domains.Journal
+	Confirmation   *Confirmation
+	Prompt         *Prompt
+	PromptInput    textinput.Model
+
+	// TagFilter and DayFilter limit ListView to journals with this tag or
+	// on this day when set.
+	TagFilter string
+	DayFilter time.Time
+
+	// Calendar state, CalendarEntries holds journals by DayKey
+	CalendarDay     time.Time
+	CalendarEntries map[string][]domains.Journal
+
+	// Tag browser state
+	Tags      []domains.Tag
+	TagCursor int
+
+	// EntryDate is the date of the entry being written, zero meaning today.
+	// PickingDate is set while AddView's date picker has the keyboard.
+	EntryDate   time.Time
+	PickingDate bool
+
+	// Dirty is set when the text or date in AddView is changed and cleared
+	// once it is saved or discarded, see Router.HasUnsavedChanges.
+	Dirty bool
+
+	// Draft is the last autosave of the entry being written, with a zero Id
+	// until there is one. Drafts are those left over from earlier sessions,
+	// offered in RecoverView.
+	Draft       domains.Draft
+	Drafts      []domains.Draft
+	DraftCursor int
+
+	// PassphraseInput unlocks an encrypted journal in UnlockView
+	PassphraseInput textinput.Model
+
+	// Idle lock state, see Router.IdleTick. LastActivity is the time of the
+	// last key press and LockedView the view that was left when the journal
+	// was locked. EditorOpen is set while $EDITOR has the terminal.
+	LockAfter    time.Duration
+	LockPIN      string
+	LastActivity time.Time
+	LockedView   constants.View
+	EditorOpen   bool
+
+	// Search state
+	SearchInput   textinput.Model
+	SearchResults []domains.SearchResult
+	SearchCursor  int
+	SearchSeq     int
+
+	// Revision history state, Revisions[0] is the current content
+	Revisions        []domains.Revision
+	RevisionCursor   int
+	RevisionBase     int
+	RevisionViewport viewport.Model
 
 	// UI components
 	Viewport        viewport.Model
@@ -35,17 +117,42 @@ type AppState struct {
 	Ready           bool
 }
 
+// NewAppState starts on the menu, or on UnlockView when the journal is
+// encrypted.
 func NewAppState(ctx context.Context, service ports.JournalService) *AppState {
+	view := constants.MenuView
+	if service.Locked() {
+		view = constants.UnlockView
+	}
 	return &AppState{
 		Ctx:             ctx,
 		Service:         service,
-		Options:         []constants.View{constants.AddView, constants.ListView, constants.EditView},
-		CurrentView:     constants.MenuView,
+		Options:         []constants.View{constants.AddView, constants.ListView, constants.CalendarView, constants.EditView, constants.SearchView, constants.TagsView, constants.TrashView},
+		CurrentView:     view,
 		RecentlySavedId: constants.UnsavedId,
+		RevisionBase:    constants.UnmarkedRevision,
 		Ready:           false,
 	}
 }
 
+// CurrentEntryDate is the date the entry in AddView will be saved with.
+func (s *AppState) CurrentEntryDate() time.Time {
+	if s.EntryDate.IsZero() {
+		return time.Now()
+	}
+	return s.EntryDate
+}
+
+// DayKey identifies the calendar day of t in local time.
+func DayKey(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// EntriesOn returns the journals loaded into the calendar for day.
+func (s *AppState) EntriesOn(day time.Time) []domains.Journal {
+	return s.CalendarEntries[DayKey(day)]
+}
+
 func (s *AppState) ResetCursorPosition() {
 	s.CursorPosition = 0
 }
@@ -58,5 +165,28 @@ func (s *AppState) MoveCursor(direction int) {
 			s.CursorPosition = newPos
 		}
 		// Remove the ListView and EditView cases since the list component handles its own cursor
+	case constants.SearchView:
+		newPos := s.SearchCursor + direction
+		if newPos >= 0 && newPos < len(s.SearchResults) {
+			s.SearchCursor = newPos
+		}
+	case constants.CalendarView:
+		s.CalendarDay = s.CalendarDay.AddDate(0, 0, 7*direction)
+	case constants.RecoverView:
+		newPos := s.DraftCursor + direction
+		if newPos >= 0 && newPos < len(s.Drafts) {
+			s.DraftCursor = newPos
+		}
+	case constants.TagsView:
+		newPos := s.TagCursor + direction
+		if newPos >= 0 && newPos < len(s.Tags) {
+			s.TagCursor = newPos
+		}
+	case constants.RevisionsView:
+		newPos := s.RevisionCursor + direction
+		if newPos >= 0 && newPos < len(s.Revisions) {
+			s.RevisionCursor = newPos
+			s.RevisionViewport.GotoTop()
+		}
 	}
 }
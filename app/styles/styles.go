@@ -32,4 +32,30 @@ var (
 
 	FooterStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241"))
+
+	SelectedStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("205"))
+
+	DiffInsertStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46"))
+
+	DiffDeleteStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196"))
+
+	CalendarEntryStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("46"))
+
+	CalendarCursorStyle = lipgloss.NewStyle().
+				Bold(true).
+				Reverse(true)
+
+	CalendarTodayStyle = lipgloss.NewStyle().
+				Underline(true)
+
+	HighlightStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("62"))
 )
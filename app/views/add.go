@@ -2,7 +2,6 @@ package views
 
 import (
 	"fmt"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -24,20 +23,26 @@ func (v AddView) Render(state *navigation.AppState) string {
 }
 
 func (v AddView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	if _, ok := msg.(navigation.DatePickerClosedMsg); ok {
+		return state.Textarea.Focus()
+	}
+
 	var cmd tea.Cmd
+	before := state.Textarea.Value()
 	state.Textarea, cmd = state.Textarea.Update(msg)
+	if state.Textarea.Value() != before {
+		state.Dirty = true
+	}
 	return cmd
 }
 
 func (v AddView) addJournalHeader(state *navigation.AppState) string {
-	var createdAt time.Time
-	if state.EditingJournal != nil {
-		createdAt = state.EditingJournal.CreatedAt
-	} else {
-		createdAt = time.Now()
+	date := state.CurrentEntryDate().Format("Mon 2 Jan, 2006")
+	if state.PickingDate {
+		date = styles.SelectedStyle.Render("‹ " + date + " ›")
 	}
 
-	header := styles.HeaderStyle.Render(fmt.Sprintf("Journal entry %s", createdAt.Format("2 Jan, 2006")))
+	header := styles.HeaderStyle.Render(fmt.Sprintf("Journal entry %s", date))
 	return header
 }
 
@@ -58,7 +63,10 @@ func (v AddView) addJournalFooter(state *navigation.AppState) string {
 		status += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
 	}
 
-	footer := styles.FooterStyle.Render("<ctrl + s>: Save | <ctrl + c>: quit")
+	footer := styles.FooterStyle.Render("<ctrl + s>: Save | <ctrl + g>: change date | <ctrl + o>: open in $EDITOR | <esc> twice: back | <ctrl + c>: quit")
+	if state.PickingDate {
+		footer = styles.FooterStyle.Render("←/→ day • ↑/↓ week • [/] month • t today • enter done")
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, status, "", footer)
 }
@@ -0,0 +1,111 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/models"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+)
+
+// cellWidth fits a two digit day with a space either side.
+const cellWidth = 4
+
+var weekdays = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+
+type CalendarView struct{}
+
+func (v CalendarView) Render(state *navigation.AppState) string {
+	header := styles.HeaderStyle.Render("Calendar")
+	subtitle := styles.FooterStyle.Render(state.CalendarDay.Format(constants.MonthFormat))
+
+	var status string
+	if state.LastError != nil {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("←h/→l day • ↑k/↓j week • [/] month • t today • enter open • backspace back")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, subtitle, "", v.monthView(state), "", v.dayView(state), status, "", footer),
+	)
+}
+
+func (v CalendarView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	return nil
+}
+
+// monthView draws the month of the highlighted day as weeks starting on
+// Monday, marking the days that have entries.
+func (v CalendarView) monthView(state *navigation.AppState) string {
+	// cells are padded after styling so only the day number is highlighted
+	cell := func(s string) string {
+		return lipgloss.PlaceHorizontal(cellWidth, lipgloss.Center, s)
+	}
+
+	var names []string
+	for _, name := range weekdays {
+		names = append(names, cell(styles.FooterStyle.Render(name)))
+	}
+	rows := []string{strings.Join(names, "")}
+
+	selected := state.CalendarDay
+	first := time.Date(selected.Year(), selected.Month(), 1, 0, 0, 0, 0, time.Local)
+	today := navigation.DayKey(time.Now())
+
+	// time.Weekday starts on Sunday
+	offset := (int(first.Weekday()) + 6) % 7
+	week := strings.Repeat(cell(""), offset)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		style := lipgloss.NewStyle()
+		key := navigation.DayKey(day)
+		if len(state.CalendarEntries[key]) > 0 {
+			style = style.Inherit(styles.CalendarEntryStyle)
+		}
+		if key == today {
+			style = style.Inherit(styles.CalendarTodayStyle)
+		}
+		if day.Day() == selected.Day() {
+			style = style.Inherit(styles.CalendarCursorStyle)
+		}
+		week += cell(style.Render(fmt.Sprintf("%2d", day.Day())))
+
+		if day.Weekday() == time.Sunday {
+			rows = append(rows, week)
+			week = ""
+		}
+	}
+	if week != "" {
+		rows = append(rows, week)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// dayView lists the entries of the highlighted day.
+func (v CalendarView) dayView(state *navigation.AppState) string {
+	day := state.CalendarDay
+	title := styles.SelectedStyle.Render(day.Format("Monday, " + constants.TimeFormat))
+
+	entries := state.EntriesOn(day)
+	if len(entries) == 0 {
+		hint := "No entries. Press enter to write one."
+		if navigation.DayKey(day) > navigation.DayKey(time.Now()) {
+			hint = "No entries."
+		}
+		return title + "\n" + hint
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	for _, journal := range entries {
+		item := models.NewJournalItem(journal)
+		fmt.Fprintf(&b, "\n%s  %s", journal.EntryDate.Format("15:04"), item.Title())
+	}
+	return b.String()
+}
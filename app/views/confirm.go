@@ -10,12 +10,21 @@ import (
 type ConfirmView struct{}
 
 func (v ConfirmView) Render(state *navigation.AppState) string {
-	header := styles.HeaderStyle.Render("Confirm Exit")
+	c := state.Confirmation
+	if c == nil {
+		return ""
+	}
 
-	content := "Unsaved changes may get lost\n\n"
+	header := styles.HeaderStyle.Render(c.Title)
+
+	content := c.Prompt + "\n\n"
 	content += "• <esc>, <backspace>: cancel\n"
-	content += "• <enter>: discard and go to main menu\n"
-	content += "• <ctrl + q>: quit"
+	content += "• <enter>: " + c.ConfirmLabel + "\n"
+	if c.OnDiscard != nil {
+		content += "• <d>: " + c.DiscardLabel
+	} else {
+		content += "• <ctrl + c>: quit"
+	}
 
 	footer := styles.FooterStyle.Render("Choose an option above")
 
@@ -7,6 +7,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/models"
 	"github.com/cheersmas/jou/app/navigation"
 	"github.com/cheersmas/jou/app/styles"
 )
@@ -32,18 +33,33 @@ func (v JournalView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
 }
 
 func (v JournalView) headerView(state *navigation.AppState) string {
-	createdAt := "Untitled"
+	date := "Untitled"
 	if state.ViewingJournal != nil {
-		createdAt = state.ViewingJournal.CreatedAt.Format(constants.TimeFormat)
+		date = state.ViewingJournal.EntryDate.Format(constants.TimeFormat)
+		if updatedAt := state.ViewingJournal.UpdatedAt; updatedAt != nil {
+			date += " · edited " + updatedAt.Format(constants.EditedTimeFormat)
+		}
+		if location := state.ViewingJournal.Location; location != "" {
+			date += " · " + location
+		}
+		if tags := state.ViewingJournal.Tags; len(tags) > 0 {
+			date += " · " + models.Hashtags(tags)
+		}
+		if title := state.ViewingJournal.Title; title != "" {
+			date = title + " · " + date
+		}
+		if state.ViewingJournal.Starred {
+			date = "★ " + date
+		}
 	}
-	title := styles.TitleStyle.Render(createdAt)
+	title := styles.TitleStyle.Render(date)
 	line := strings.Repeat("─", max(0, state.Viewport.Width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (v JournalView) footerView(state *navigation.AppState) string {
 	// Create the navigation footer on the left
-	navFooter := styles.FooterStyle.Render("↑k up • ↓j down • esc back to list • ctrl+c quit")
+	navFooter := styles.FooterStyle.Render("↑k up • ↓j down • e edit • n title • t tags • h history • d delete • esc back to list • ctrl+c quit")
 
 	// Create the scroll percentage on the right
 	scrollInfo := styles.InfoStyle.Render(fmt.Sprintf("%3.f%%", state.Viewport.ScrollPercent()*100))
@@ -0,0 +1,37 @@
+package views
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+)
+
+type PromptView struct{}
+
+func (v PromptView) Render(state *navigation.AppState) string {
+	p := state.Prompt
+	if p == nil {
+		return ""
+	}
+
+	header := styles.HeaderStyle.Render(p.Title)
+	content := p.Prompt + "\n\n" + state.PromptInput.View()
+	if p.Err != nil {
+		content += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", p.Err))
+	}
+
+	footer := styles.FooterStyle.Render("enter save • esc cancel • ctrl+c quit")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, "", content, "", footer),
+	)
+}
+
+func (v PromptView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	state.PromptInput, cmd = state.PromptInput.Update(msg)
+	return cmd
+}
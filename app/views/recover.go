@@ -0,0 +1,60 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+	"github.com/cheersmas/jou/domains"
+)
+
+type RecoverView struct{}
+
+func (v RecoverView) Render(state *navigation.AppState) string {
+	header := styles.HeaderStyle.Render("Recover Drafts")
+	drafts := "drafts were"
+	if len(state.Drafts) == 1 {
+		drafts = "draft was"
+	}
+	subtitle := styles.FooterStyle.Render(fmt.Sprintf("jou closed before %d %s saved", len(state.Drafts), drafts))
+
+	var b strings.Builder
+	for i, draft := range state.Drafts {
+		line := fmt.Sprintf("%s  %s", draft.UpdatedAt.Format(constants.EditedTimeFormat), v.describe(draft))
+		if i == state.DraftCursor {
+			line = styles.SelectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	content := strings.TrimRight(b.String(), "\n")
+
+	var status string
+	if state.LastError != nil {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("↑k up • ↓j down • enter/r restore • s save • x discard • esc later")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, subtitle, "", content, status, "", footer),
+	)
+}
+
+func (v RecoverView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	return nil
+}
+
+// describe tells new entries apart from unsaved edits of existing ones.
+func (v RecoverView) describe(draft domains.Draft) string {
+	title := domains.DeriveTitle(draft.Content)
+	if draft.JournalId != 0 {
+		return fmt.Sprintf("edit of #%d · %s", draft.JournalId, title)
+	}
+	return "new entry · " + title
+}
@@ -0,0 +1,132 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/models"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+)
+
+// RevisionsColumnWidth is the width of the revision list beside the diff.
+const RevisionsColumnWidth = 28
+
+type RevisionsView struct{}
+
+func (v RevisionsView) Render(state *navigation.AppState) string {
+	if len(state.Revisions) == 0 {
+		return styles.ContainerStyle.Render("No history")
+	}
+
+	header := styles.HeaderStyle.Render("History")
+	from, to := v.comparison(state)
+	subtitle := styles.FooterStyle.Render(fmt.Sprintf("%s → %s", v.label(state, from), v.label(state, to)))
+
+	// Render from a copy so the diff shows even before the first Update.
+	diff := state.RevisionViewport
+	diff.SetContent(v.diffView(state))
+
+	revisions := lipgloss.NewStyle().Width(RevisionsColumnWidth).Render(v.revisionsView(state))
+	body := lipgloss.JoinHorizontal(lipgloss.Top, revisions, "  ", diff.View())
+
+	var status string
+	if state.LastError != nil {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("↑k up • ↓j down • space mark base • r restore • pgup/pgdn scroll • backspace back")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, subtitle, "", body, status, "", footer),
+	)
+}
+
+func (v RevisionsView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	if len(state.Revisions) == 0 {
+		return nil
+	}
+
+	state.RevisionViewport.SetContent(v.diffView(state))
+
+	var cmd tea.Cmd
+	state.RevisionViewport, cmd = state.RevisionViewport.Update(msg)
+	return cmd
+}
+
+// comparison returns the indexes of the revisions being diffed. Without a
+// marked base the highlighted revision is compared with the one before it;
+// from is -1 for the very first version.
+func (v RevisionsView) comparison(state *navigation.AppState) (from, to int) {
+	to = state.RevisionCursor
+	if state.RevisionBase != constants.UnmarkedRevision && state.RevisionBase != to {
+		return state.RevisionBase, to
+	}
+	if to+1 < len(state.Revisions) {
+		return to + 1, to
+	}
+	return -1, to
+}
+
+func (v RevisionsView) diffView(state *navigation.AppState) string {
+	from, to := v.comparison(state)
+	var fromContent string
+	if from >= 0 {
+		fromContent = state.Revisions[from].Content
+	}
+	return renderDiff(models.LineDiff(fromContent, state.Revisions[to].Content))
+}
+
+func (v RevisionsView) label(state *navigation.AppState, index int) string {
+	switch index {
+	case -1:
+		return "empty"
+	case 0:
+		return "current"
+	}
+	return state.Revisions[index].CreatedAt.Format(constants.EditedTimeFormat)
+}
+
+func (v RevisionsView) revisionsView(state *navigation.AppState) string {
+	var b strings.Builder
+	for i := range state.Revisions {
+		cursor := "  "
+		if i == state.RevisionCursor {
+			cursor = "> "
+		}
+		mark := "  "
+		if i == state.RevisionBase {
+			mark = "● "
+		}
+
+		line := cursor + mark + v.label(state, i)
+		if i == state.RevisionCursor {
+			line = styles.SelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderDiff(lines []models.DiffLine) string {
+	if len(lines) == 0 {
+		return styles.FooterStyle.Render("No differences")
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		switch line.Op {
+		case models.DiffInsert:
+			b.WriteString(styles.DiffInsertStyle.Render("+ " + line.Text))
+		case models.DiffDelete:
+			b.WriteString(styles.DiffDeleteStyle.Render("- " + line.Text))
+		default:
+			b.WriteString("  " + line.Text)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
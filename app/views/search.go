@@ -0,0 +1,135 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+	"github.com/cheersmas/jou/domains"
+)
+
+// searchDebounceMsg fires once typing has paused; it is stale if another key
+// was pressed since it was scheduled.
+type searchDebounceMsg struct {
+	seq int
+}
+
+type searchResultsMsg struct {
+	seq     int
+	results []domains.SearchResult
+	err     error
+}
+
+type SearchView struct{}
+
+func (v SearchView) Render(state *navigation.AppState) string {
+	header := styles.HeaderStyle.Render("Search")
+	input := state.SearchInput.View()
+
+	var results string
+	switch {
+	case state.SearchInput.Value() == "":
+		results = styles.FooterStyle.Render(`Try words, "exact phrases", prefix* or a OR b`)
+	case len(state.SearchResults) == 0:
+		results = styles.FooterStyle.Render("No matches")
+	default:
+		results = v.resultsView(state)
+	}
+
+	var status string
+	if state.LastError != nil {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("↑ up • ↓ down • enter open • esc back • ctrl+c quit")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, "", input, "", results, status, "", footer),
+	)
+}
+
+func (v SearchView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case searchDebounceMsg:
+		if msg.seq != state.SearchSeq {
+			return nil
+		}
+		return v.search(state, msg.seq)
+	case searchResultsMsg:
+		if msg.seq != state.SearchSeq {
+			return nil
+		}
+		state.SearchResults = msg.results
+		state.SearchCursor = 0
+		state.LastError = msg.err
+		return nil
+	}
+
+	query := state.SearchInput.Value()
+	var cmd tea.Cmd
+	state.SearchInput, cmd = state.SearchInput.Update(msg)
+	if state.SearchInput.Value() == query {
+		return cmd
+	}
+
+	state.SearchSeq++
+	seq := state.SearchSeq
+	debounce := tea.Tick(constants.SearchDebounce, func(time.Time) tea.Msg {
+		return searchDebounceMsg{seq: seq}
+	})
+	return tea.Batch(cmd, debounce)
+}
+
+func (v SearchView) search(state *navigation.AppState, seq int) tea.Cmd {
+	ctx, service, query := state.Ctx, state.Service, state.SearchInput.Value()
+	return func() tea.Msg {
+		results, err := service.Search(ctx, query)
+		return searchResultsMsg{seq: seq, results: results, err: err}
+	}
+}
+
+// resultsView shows the results that fit the terminal, scrolled to keep the
+// cursor in view. Each result takes three lines.
+func (v SearchView) resultsView(state *navigation.AppState) string {
+	height := max(1, state.Viewport.Height/3)
+	start := max(0, min(state.SearchCursor-height/2, len(state.SearchResults)-height))
+	end := min(len(state.SearchResults), start+height)
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		result := state.SearchResults[i]
+		cursor := "  "
+		heading := result.Journal.EntryDate.Format(constants.TimeFormat) + " · " + result.Journal.DisplayTitle()
+		date := styles.FooterStyle.Render(heading)
+		if i == state.SearchCursor {
+			cursor = "> "
+			date = styles.SelectedStyle.Render(heading)
+		}
+		fmt.Fprintf(&b, "%s%s\n  %s\n\n", cursor, date, highlight(result.Snippet))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// highlight renders the matched terms of a search snippet, flattening it to a
+// single line.
+func highlight(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+
+	var b strings.Builder
+	for {
+		before, rest, ok := strings.Cut(snippet, domains.HighlightStart)
+		b.WriteString(before)
+		if !ok {
+			break
+		}
+		hit, after, _ := strings.Cut(rest, domains.HighlightEnd)
+		b.WriteString(styles.HighlightStyle.Render(hit))
+		snippet = after
+	}
+	return b.String()
+}
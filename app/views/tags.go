@@ -0,0 +1,68 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/constants"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+)
+
+type TagsView struct{}
+
+func (v TagsView) Render(state *navigation.AppState) string {
+	header := styles.HeaderStyle.Render("Tags")
+	subtitle := styles.FooterStyle.Render(fmt.Sprintf("%d tags", len(state.Tags)))
+
+	content := "No tags yet. Write #tags in an entry, or press t while reading one."
+	if len(state.Tags) > 0 {
+		content = v.tagsView(state)
+	}
+
+	var status string
+	if state.LastError != nil {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("↑k up • ↓j down • enter entries • r rename • m merge • backspace back")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, subtitle, "", content, status, "", footer),
+	)
+}
+
+func (v TagsView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	return nil
+}
+
+// tagsView lists the tags in a window that follows the cursor.
+func (v TagsView) tagsView(state *navigation.AppState) string {
+	height := max(5, state.Viewport.Height-4)
+	start := max(0, min(state.TagCursor-height/2, len(state.Tags)-height))
+	end := min(len(state.Tags), start+height)
+
+	width := 0
+	for _, tag := range state.Tags {
+		width = max(width, lipgloss.Width(tag.Name)+1)
+	}
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		tag := state.Tags[i]
+		entries := "entries"
+		if tag.Count == 1 {
+			entries = "entry"
+		}
+		line := fmt.Sprintf("%-*s  %4d %-7s  last used %s", width, "#"+tag.Name, tag.Count, entries, tag.LastUsed.Format(constants.TimeFormat))
+		if i == state.TagCursor {
+			line = styles.SelectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
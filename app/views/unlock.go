@@ -0,0 +1,55 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cheersmas/jou/app/navigation"
+	"github.com/cheersmas/jou/app/styles"
+)
+
+type UnlockView struct{}
+
+func (v UnlockView) Render(state *navigation.AppState) string {
+	header := styles.HeaderStyle.Render("Unlock")
+	text := "This journal is encrypted. Enter its passphrase to open it."
+	if state.LockedView != "" {
+		secret := "passphrase"
+		if !state.Service.Encrypted() {
+			secret = "PIN"
+		}
+		text = fmt.Sprintf("jou was locked after %s without input. Enter your %s to continue.", shortDuration(state.LockAfter), secret)
+	}
+	content := text + "\n\n" + state.PassphraseInput.View()
+	if state.LastError != nil {
+		content += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(fmt.Sprintf("✗ Error: %v", state.LastError))
+	}
+
+	footer := styles.FooterStyle.Render("enter unlock • esc quit")
+
+	return styles.ContainerStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, "", content, "", footer),
+	)
+}
+
+func (v UnlockView) Update(state *navigation.AppState, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	state.PassphraseInput, cmd = state.PassphraseInput.Update(msg)
+	return cmd
+}
+
+// shortDuration drops the zero units time.Duration prints, "5m" instead of
+// "5m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
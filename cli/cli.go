@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheersmas/jou/ports"
+	"github.com/mattn/go-isatty"
+)
+
+// ErrUsage is returned when a command is called with the wrong arguments.
+// Its usage has already been printed.
+var ErrUsage = errors.New("invalid usage")
+
+type command struct {
+	usage   string
+	summary string
+	run     func(c *CLI, args []string) error
+	// locked commands run without unlocking an encrypted journal first
+	locked bool
+}
+
+// commands is filled in init because the commands look up their own usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"add":        {usage: "add [--date DATE] [--title T] [TEXT...]", summary: "create an entry from TEXT or stdin", run: (*CLI).add},
+		"list":       {usage: "list [--since 7d|DATE] [--tag TAG] [--format F]", summary: "list entries, newest first", run: (*CLI).list},
+		"show":       {usage: "show [--format F] ID", summary: "print an entry", run: (*CLI).show},
+		"edit":       {usage: "edit ID [TEXT...] | edit [--date DATE] [--title T] ID", summary: "replace an entry's content with TEXT, stdin or $EDITOR", run: (*CLI).edit},
+		"rm":         {usage: "rm ID...", summary: "move entries to the trash", run: (*CLI).rm},
+		"tag":        {usage: "tag ID [+TAG|-TAG...]", summary: "show, add or remove an entry's tags", run: (*CLI).tag},
+		"tags":       {usage: "tags [rename OLD NEW | merge OLD INTO]", summary: "list every tag with its entry count, or rename and merge tags", run: (*CLI).tags},
+		"export":     {usage: "export markdown DIR | jrnl FILE", summary: "write every entry as Markdown under DIR/YYYY/MM, or to a jrnl text file (- for stdout)", run: (*CLI).export},
+		"import":     {usage: "import [--dry-run] markdown|dayone|jrnl PATH", summary: "import Markdown files, a Day One export or a jrnl text file, skipping entries already present", run: (*CLI).importEntries},
+		"encrypt":    {usage: "encrypt", summary: "encrypt every entry with a passphrase", run: (*CLI).encrypt, locked: true},
+		"decrypt":    {usage: "decrypt", summary: "remove the passphrase and store entries as plaintext again", run: (*CLI).decrypt},
+		"passphrase": {usage: "passphrase", summary: "change the passphrase of an encrypted journal", run: (*CLI).changePassphrase},
+	}
+}
+
+// CLI runs jou's non-interactive commands against a journal service.
+type CLI struct {
+	ctx     context.Context
+	service ports.JournalService
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func New(ctx context.Context, service ports.JournalService) *CLI {
+	return &CLI{
+		ctx:     ctx,
+		service: service,
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+}
+
+// Run dispatches args, whose first element is the command name.
+func (c *CLI) Run(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.Usage(c.stdout)
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		c.Usage(c.stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	if !cmd.locked && c.service.Locked() {
+		if err := c.unlock(); err != nil {
+			return err
+		}
+	}
+	return cmd.run(c, args[1:])
+}
+
+// Usage lists every command.
+func (c *CLI) Usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: jou [--db PATH] [COMMAND]")
+	fmt.Fprintln(w, "\nWithout a command jou opens the interactive journal.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-32s %s\n", commands[name].usage, commands[name].summary)
+	}
+}
+
+// text joins args into an entry, reading stdin when there are none.
+func (c *CLI) text(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+
+	if isTerminal(c.stdin) {
+		fmt.Fprintln(c.stderr, "Reading entry from stdin, finish with Ctrl+D")
+	}
+
+	b, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return string(b), nil
+}
+
+// isTerminal reports whether stdin, stdout or stderr is a terminal.
+func isTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+func parseId(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid entry id %q", s)
+	}
+	return id, nil
+}
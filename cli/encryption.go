@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cheersmas/jou/domains"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv lets scripts unlock an encrypted journal without a terminal.
+const PassphraseEnv = "JOU_PASSPHRASE"
+
+func (c *CLI) encrypt(args []string) error {
+	fs := c.flagSet("encrypt")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+	if c.service.Encrypted() {
+		return domains.ErrEncrypted
+	}
+
+	passphrase, ok := os.LookupEnv(PassphraseEnv)
+	if !ok {
+		var err error
+		if passphrase, err = newPassphrase(); err != nil {
+			return err
+		}
+	}
+	if err := c.service.Encrypt(c.ctx, passphrase); err != nil {
+		return fmt.Errorf("failed to encrypt journal: %w", err)
+	}
+	fmt.Fprintln(c.stdout, "Encrypted the journal. There is no way to read it without the passphrase.")
+	fmt.Fprintln(c.stdout, "Dates, stars and which entries share a tag are not encrypted.")
+	fmt.Fprintln(c.stdout, "Backups made before upgrades (*.bak next to the database) are not encrypted, delete them if you no longer need them.")
+	return nil
+}
+
+func (c *CLI) decrypt(args []string) error {
+	fs := c.flagSet("decrypt")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	if err := c.service.Decrypt(c.ctx); err != nil {
+		return fmt.Errorf("failed to decrypt journal: %w", err)
+	}
+	fmt.Fprintln(c.stdout, "Decrypted the journal, entries are stored as plaintext again.")
+	return nil
+}
+
+// changePassphrase re-encrypts the journal under a new passphrase, showing
+// how far it got on a terminal.
+func (c *CLI) changePassphrase(args []string) error {
+	fs := c.flagSet("passphrase")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+	if !c.service.Encrypted() {
+		return fmt.Errorf("%w, use encrypt to set a passphrase", domains.ErrNotEncrypted)
+	}
+
+	passphrase, err := newPassphrase()
+	if err != nil {
+		return err
+	}
+
+	var progress func(done, total int)
+	if isTerminal(c.stderr) {
+		progress = func(done, total int) {
+			fmt.Fprintf(c.stderr, "\rRe-encrypting %d/%d", done, total)
+			if done == total {
+				fmt.Fprintln(c.stderr)
+			}
+		}
+	}
+	if err := c.service.ChangePassphrase(c.ctx, passphrase, progress); err != nil {
+		if progress != nil {
+			fmt.Fprintln(c.stderr)
+		}
+		return fmt.Errorf("failed to change passphrase, the journal still uses the old one: %w", err)
+	}
+	fmt.Fprintln(c.stdout, "Changed the passphrase.")
+	return nil
+}
+
+// unlock opens an encrypted journal with the passphrase from PassphraseEnv,
+// or asks for it on the terminal.
+func (c *CLI) unlock() error {
+	passphrase, ok := os.LookupEnv(PassphraseEnv)
+	if !ok {
+		var err error
+		if passphrase, err = readPassphrase("Passphrase: "); err != nil {
+			return err
+		}
+	}
+	return c.service.Unlock(c.ctx, passphrase)
+}
+
+// newPassphrase asks for a passphrase twice so a typo does not lock the
+// journal for good.
+func newPassphrase() (string, error) {
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", domains.ErrEmptyPassphrase
+	}
+	again, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readPassphrase reads a line from the terminal without echoing it. It uses
+// the terminal directly so entries can still be piped in on stdin.
+func readPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal to read the passphrase from, set %s", PassphraseEnv)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	passphrase, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cheersmas/jou/exchange"
+)
+
+func (c *CLI) export(args []string) error {
+	fs := c.flagSet("export")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	switch kind, dest := fs.Arg(0), fs.Arg(1); kind {
+	case "markdown":
+		report, err := exchange.ExportMarkdown(c.ctx, c.service, dest)
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		fmt.Fprintf(c.stdout, "Exported to %s: %d written, %d unchanged, %d removed\n", dest, report.Written, report.Unchanged, report.Removed)
+		return nil
+	case "jrnl":
+		return c.exportJrnl(dest)
+	default:
+		return fmt.Errorf("unknown export format %q, use markdown or jrnl", kind)
+	}
+}
+
+// exportJrnl writes a jrnl text file, or to stdout when dest is "-" so the
+// output can be piped into jrnl's own import.
+func (c *CLI) exportJrnl(dest string) error {
+	var w io.Writer = c.stdout
+	if dest != "-" {
+		f, err := os.Create(dest)
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	count, err := exchange.ExportJrnl(c.ctx, c.service, w)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if dest != "-" {
+		fmt.Fprintf(c.stdout, "Exported %d entries to %s\n", count, dest)
+	}
+	return nil
+}
+
+func (c *CLI) importJrnl(im *exchange.Importer, source string) (exchange.ImportReport, error) {
+	if source == "-" {
+		return exchange.ImportJrnl(c.ctx, im, "stdin", c.stdin)
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return exchange.ImportReport{}, err
+	}
+	defer f.Close()
+	return exchange.ImportJrnl(c.ctx, im, source, f)
+}
+
+func (c *CLI) importEntries(args []string) error {
+	fs := c.flagSet("import")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	im, err := exchange.NewImporter(c.ctx, c.service, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	var report exchange.ImportReport
+	switch kind, source := fs.Arg(0), fs.Arg(1); kind {
+	case "markdown":
+		report, err = exchange.ImportMarkdown(c.ctx, im, source)
+	case "dayone":
+		report, err = exchange.ImportDayOne(c.ctx, im, source)
+	case "jrnl":
+		report, err = c.importJrnl(im, source)
+	default:
+		return fmt.Errorf("unknown import format %q, use markdown, dayone or jrnl", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	c.printImportReport(report)
+	if failed := report.Count(exchange.Failed); failed > 0 {
+		return fmt.Errorf("import finished with %d failures", failed)
+	}
+	return nil
+}
+
+func (c *CLI) printImportReport(report exchange.ImportReport) {
+	imported := "imported"
+	if report.DryRun {
+		imported = "would import"
+	}
+
+	for _, result := range report.Results {
+		date := ""
+		if !result.EntryDate.IsZero() {
+			date = result.EntryDate.Format(DateFormat)
+		}
+
+		switch result.Status {
+		case exchange.Imported:
+			line := fmt.Sprintf("%-12s  %-10s  %s", imported, date, result.Source)
+			if result.Id > 0 {
+				line += fmt.Sprintf(" (entry %d)", result.Id)
+			}
+			fmt.Fprintln(c.stdout, line)
+		case exchange.Duplicate:
+			fmt.Fprintf(c.stdout, "%-12s  %-10s  %s\n", "duplicate", date, result.Source)
+		case exchange.Failed:
+			fmt.Fprintf(c.stderr, "%-12s  %-10s  %s: %v\n", "failed", date, result.Source, result.Err)
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "\n%d %s, %d duplicates skipped, %d failed\n",
+		report.Count(exchange.Imported), imported, report.Count(exchange.Duplicate), report.Count(exchange.Failed))
+}
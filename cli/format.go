@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+type format string
+
+const (
+	formatText   format = "text"
+	formatJSON   format = "json"
+	formatNDJSON format = "ndjson"
+	formatCSV    format = "csv"
+)
+
+// csvHeader is the column order of CSV output. Columns are only ever
+// appended so scripts can rely on their position.
+var csvHeader = []string{"id", "createdAt", "updatedAt", "deletedAt", "content", "starred", "location", "entryDate", "tags", "title"}
+
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", string(formatText), "output format: text, json, ndjson or csv")
+}
+
+func parseFormat(s string) (format, error) {
+	switch f := format(s); f {
+	case formatText, formatJSON, formatNDJSON, formatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, use text, json, ndjson or csv", s)
+}
+
+// writeJournals prints journals in a machine readable format. A single
+// journal is printed as a JSON object rather than an array when one is false.
+func (c *CLI) writeJournals(f format, journals []domains.Journal, many bool) error {
+	switch f {
+	case formatJSON:
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		if !many && len(journals) == 1 {
+			return enc.Encode(journals[0])
+		}
+		if journals == nil {
+			journals = []domains.Journal{}
+		}
+		return enc.Encode(journals)
+	case formatNDJSON:
+		enc := json.NewEncoder(c.stdout)
+		for _, journal := range journals {
+			if err := enc.Encode(journal); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatCSV:
+		w := csv.NewWriter(c.stdout)
+		if err := w.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, journal := range journals {
+			if err := w.Write(csvRecord(journal)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return fmt.Errorf("format %q is not machine readable", f)
+}
+
+func csvRecord(journal domains.Journal) []string {
+	return []string{
+		strconv.Itoa(journal.Id),
+		journal.CreatedAt.Format(time.RFC3339),
+		optionalTime(journal.UpdatedAt),
+		optionalTime(journal.DeletedAt),
+		journal.Content,
+		strconv.FormatBool(journal.Starred),
+		journal.Location,
+		journal.EntryDate.Format(time.RFC3339),
+		strings.Join(journal.Tags, " "),
+		journal.Title,
+	}
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/config"
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/editor"
+)
+
+const (
+	TimeFormat = "2006-01-02 15:04"
+	DateFormat = "2006-01-02"
+
+	summaryLength = 60
+)
+
+func (c *CLI) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: jou %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func (c *CLI) add(args []string) error {
+	fs := c.flagSet("add")
+	dateFlag := fs.String("date", "", "backdate the entry to a date (YYYY-MM-DD [HH:MM]), yesterday or a duration ago (3d)")
+	title := fs.String("title", "", "title the entry instead of using its first heading or line")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+
+	var date time.Time
+	if *dateFlag != "" {
+		var err error
+		if date, err = parseEntryDate(*dateFlag, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	content, err := c.text(fs.Args())
+	if err != nil {
+		return err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return fmt.Errorf("refusing to add an empty entry")
+	}
+
+	id, err := c.service.Create(c.ctx, domains.Journal{Title: *title, Content: content, EntryDate: date})
+	if err != nil {
+		return fmt.Errorf("failed to create entry: %w", err)
+	}
+	fmt.Fprintf(c.stdout, "Created entry %d\n", id)
+	return nil
+}
+
+func (c *CLI) list(args []string) error {
+	fs := c.flagSet("list")
+	since := fs.String("since", "", "only entries newer than a duration (7d, 2w, 12h) or a date (YYYY-MM-DD)")
+	tag := fs.String("tag", "", "only entries tagged TAG")
+	formatName := formatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	f, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	var after time.Time
+	if *since != "" {
+		if after, err = parseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	var journals []domains.Journal
+	if *tag != "" {
+		journals, err = c.service.ListByTag(c.ctx, *tag)
+	} else {
+		journals, err = c.service.ListAll(c.ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list entries: %w", err)
+	}
+
+	var matching []domains.Journal
+	for _, journal := range journals {
+		if !journal.EntryDate.Before(after) {
+			matching = append(matching, journal)
+		}
+	}
+
+	if f != formatText {
+		return c.writeJournals(f, matching, true)
+	}
+	for _, journal := range matching {
+		fmt.Fprintf(c.stdout, "%4d  %s  %s\n", journal.Id, journal.EntryDate.Format(TimeFormat), summary(journal.DisplayTitle()))
+	}
+	return nil
+}
+
+func (c *CLI) show(args []string) error {
+	fs := c.flagSet("show")
+	formatName := formatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ErrUsage
+	}
+	f, err := parseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	journal, err := c.service.Read(c.ctx, id)
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		return c.writeJournals(f, []domains.Journal{journal}, false)
+	}
+
+	header := fmt.Sprintf("Entry %d · %s", journal.Id, journal.EntryDate.Format(TimeFormat))
+	if journal.CreatedAt.Format(DateFormat) != journal.EntryDate.Format(DateFormat) {
+		header += " · written " + journal.CreatedAt.Format(TimeFormat)
+	}
+	if journal.UpdatedAt != nil {
+		header += " · edited " + journal.UpdatedAt.Format(TimeFormat)
+	}
+	if journal.DeletedAt != nil {
+		header += " · in trash since " + journal.DeletedAt.Format(TimeFormat)
+	}
+	if len(journal.Tags) > 0 {
+		header += " · " + hashtags(journal.Tags)
+	}
+	if journal.Title != "" {
+		header = journal.Title + "\n" + header
+	}
+	fmt.Fprintf(c.stdout, "%s\n\n%s\n", header, strings.TrimRight(journal.Content, "\n"))
+	return nil
+}
+
+func (c *CLI) edit(args []string) error {
+	fs := c.flagSet("edit")
+	dateFlag := fs.String("date", "", "move the entry to another date instead of editing its text")
+	title := fs.String("title", "", "retitle the entry instead of editing its text, \"\" to use its first line again")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	titleSet := false
+	fs.Visit(func(f *flag.Flag) { titleSet = titleSet || f.Name == "title" })
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	journal, err := c.service.Read(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *dateFlag != "" || titleSet {
+		if fs.NArg() > 1 {
+			return fmt.Errorf("--date and --title change an entry's details, edit its text separately")
+		}
+		if *dateFlag != "" {
+			date, err := parseEntryDate(*dateFlag, time.Now())
+			if err != nil {
+				return err
+			}
+			if _, err := c.service.SetEntryDate(c.ctx, id, date); err != nil {
+				return fmt.Errorf("failed to update entry: %w", err)
+			}
+			// A later time today is moved back to now.
+			if journal, err = c.service.Read(c.ctx, id); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stdout, "Moved entry %d to %s\n", id, journal.EntryDate.Format(TimeFormat))
+		}
+		if titleSet {
+			if _, err := c.service.SetTitle(c.ctx, id, *title); err != nil {
+				return fmt.Errorf("failed to update entry: %w", err)
+			}
+			fmt.Fprintf(c.stdout, "Retitled entry %d\n", id)
+		}
+		return nil
+	}
+
+	var content string
+	if fs.NArg() == 1 && isTerminal(c.stdin) {
+		if c.service.Encrypted() {
+			return fmt.Errorf("%w; pass the new text as arguments or on stdin", editor.ErrEncrypted)
+		}
+		content, err = editor.Edit(journal.Content, c.stdin, c.stdout, c.stderr)
+	} else {
+		content, err = c.text(fs.Args()[1:])
+	}
+	if err != nil {
+		return err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return fmt.Errorf("refusing to empty entry %d, use rm to delete it", id)
+	}
+	if content == strings.TrimSpace(journal.Content) {
+		fmt.Fprintf(c.stdout, "No changes to entry %d\n", id)
+		return nil
+	}
+
+	if _, err := c.service.Update(c.ctx, id, content); err != nil {
+		return fmt.Errorf("failed to update entry: %w", err)
+	}
+	fmt.Fprintf(c.stdout, "Updated entry %d\n", id)
+	return nil
+}
+
+func (c *CLI) rm(args []string) error {
+	fs := c.flagSet("rm")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	for _, arg := range fs.Args() {
+		id, err := parseId(arg)
+		if err != nil {
+			return err
+		}
+		if _, err := c.service.Delete(c.ctx, id); err != nil {
+			return fmt.Errorf("failed to delete entry %d: %w", id, err)
+		}
+		fmt.Fprintf(c.stdout, "Moved entry %d to the trash\n", id)
+	}
+	return nil
+}
+
+// parseSince accepts either a duration back from now or a calendar date.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(DateFormat, s, time.Local); err == nil {
+		return date, nil
+	}
+	d, err := config.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, use a duration like 7d or a date like 2006-01-02", s)
+	}
+	return now.Add(-d), nil
+}
+
+// parseEntryDate accepts a calendar date with an optional time, "today",
+// "yesterday" or a duration back from now. A date without a time keeps the
+// current time of day so entries written the same day stay in order.
+func parseEntryDate(s string, now time.Time) (time.Time, error) {
+	switch s {
+	case "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+	if date, err := time.ParseInLocation(TimeFormat, s, time.Local); err == nil {
+		return date, nil
+	}
+	if date, err := time.ParseInLocation(DateFormat, s, time.Local); err == nil {
+		return time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local), nil
+	}
+	d, err := config.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --date %q, use a date like 2006-01-02, yesterday or a duration like 3d", s)
+	}
+	return now.Add(-d), nil
+}
+
+// summary shortens a title to fit a listing.
+func summary(line string) string {
+	if runes := []rune(line); len(runes) > summaryLength {
+		return string(runes[:summaryLength-1]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+func (c *CLI) tag(args []string) error {
+	fs := c.flagSet("tag")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	journal, err := c.service.Read(c.ctx, id)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		fmt.Fprintln(c.stdout, hashtags(journal.Tags))
+		return nil
+	}
+
+	// Only explicit tags can be changed here, inline ones live in the text.
+	tags := journal.ExtraTags()
+	inline := domains.ExtractTags(journal.Content)
+	for _, arg := range fs.Args()[1:] {
+		remove := strings.HasPrefix(arg, "-")
+		name, err := domains.NormalizeTag(strings.TrimLeft(arg, "+-"))
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case remove && slices.Contains(inline, name):
+			return fmt.Errorf("#%s is written in entry %d, edit its text to remove it", name, id)
+		case remove:
+			tags = slices.DeleteFunc(tags, func(tag string) bool { return tag == name })
+		case !slices.Contains(tags, name):
+			tags = append(tags, name)
+		}
+	}
+
+	if _, err := c.service.SetTags(c.ctx, id, tags); err != nil {
+		return fmt.Errorf("failed to tag entry: %w", err)
+	}
+	if journal, err = c.service.Read(c.ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Entry %d: %s\n", id, hashtags(journal.Tags))
+	return nil
+}
+
+func (c *CLI) tags(args []string) error {
+	fs := c.flagSet("tags")
+	if err := fs.Parse(args); err != nil {
+		return ErrUsage
+	}
+
+	if fs.NArg() > 0 {
+		return c.renameTag(fs)
+	}
+
+	tags, err := c.service.ListTags(c.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	for _, tag := range tags {
+		fmt.Fprintf(c.stdout, "%-24s %4d  last used %s\n", "#"+tag.Name, tag.Count, tag.LastUsed.Format(DateFormat))
+	}
+	return nil
+}
+
+func (c *CLI) renameTag(fs *flag.FlagSet) error {
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	from, err := domains.NormalizeTag(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	to, err := domains.NormalizeTag(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "rename":
+		n, err := c.service.RenameTag(c.ctx, from, to)
+		if err != nil {
+			if errors.Is(err, domains.ErrTagExists) {
+				return fmt.Errorf("%w, use merge to combine them", err)
+			}
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Renamed #%s to #%s on %d entries\n", from, to, n)
+	case "merge":
+		n, err := c.service.MergeTags(c.ctx, from, to)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Merged #%s into #%s on %d entries\n", from, to, n)
+	default:
+		fs.Usage()
+		return ErrUsage
+	}
+	return nil
+}
+
+// hashtags formats tags the way they are written in entries.
+func hashtags(tags []string) string {
+	if len(tags) == 0 {
+		return "no tags"
+	}
+	return "#" + strings.Join(tags, " #")
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AppName  = "jou"
+	FileName = "config"
+)
+
+// Config holds user settings read from the config file. Settings missing
+// from the file keep the values from Default.
+type Config struct {
+	// DatabasePath is empty unless set, see database.ResolvePath.
+	DatabasePath string
+	// TrashRetention is how long deleted entries stay in the trash, zero
+	// keeps them forever.
+	TrashRetention time.Duration
+	// LockAfter locks the interactive journal after this long without a key
+	// press, zero never locks it. Only encrypted journals and those with a
+	// LockPIN can be locked.
+	LockAfter time.Duration
+	// LockPIN unlocks a journal locked after LockAfter. Encrypted journals
+	// ask for their passphrase instead.
+	LockPIN string
+}
+
+func Default() Config {
+	return Config{
+		TrashRetention: 30 * Day,
+	}
+}
+
+// Dir returns the directory jou reads its config file from, following the
+// XDG base directory spec.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, AppName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", AppName), nil
+}
+
+// DataDir returns the directory jou keeps its data in, following the XDG
+// base directory spec.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, AppName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", AppName), nil
+}
+
+// Load reads the config file from the config directory. A missing file is
+// not an error and yields the Default config.
+func Load() (Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFile(filepath.Join(dir, FileName))
+}
+
+// LoadFile parses a config file made of "key = value" lines. Blank lines and
+// lines starting with # are ignored.
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return cfg, fmt.Errorf("%s:%d: expected key = value", path, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch key {
+		case "db":
+			cfg.DatabasePath = value
+		case "trash_retention":
+			if cfg.TrashRetention, err = ParseDuration(value); err != nil {
+				return cfg, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+		case "lock_after":
+			if cfg.LockAfter, err = ParseDuration(value); err != nil {
+				return cfg, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+		case "lock_pin":
+			cfg.LockPIN = value
+		default:
+			return cfg, fmt.Errorf("%s:%d: unknown key %q", path, lineNo, key)
+		}
+	}
+
+	return cfg, scanner.Err()
+}
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// ParseDuration extends time.ParseDuration with whole days ("30d") and weeks
+// ("2w"). A bare "0" is accepted too.
+func ParseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": Day, "w": Week} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -2,41 +2,67 @@ package database
 
 import (
 	"database/sql"
-	"sync"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/cheersmas/jou/config"
 	_ "modernc.org/sqlite"
 )
 
 const (
-	DB_DRIVER_NAME      = "sqlite"
-	DB_DATA_SOURCE_NAME = "lite.db"
+	DB_DRIVER_NAME = "sqlite"
+	DB_FILE_NAME   = "jou.db"
+	DB_PATH_ENV    = "JOU_DB"
 )
 
-var (
-	instance *sql.DB
-	once     sync.Once
-	initErr  error
-)
-
-// NewDatabase returns a singleton instance of the database connection
-func NewDatabase() (*sql.DB, error) {
-	once.Do(func() {
-		instance, initErr = sql.Open(DB_DRIVER_NAME, DB_DATA_SOURCE_NAME)
-		if initErr != nil {
-			return
+// ResolvePath picks the database file to open. The first non-empty value
+// wins: the --db flag, the JOU_DB environment variable, the db key from the
+// config file and finally $XDG_DATA_HOME/jou/jou.db.
+func ResolvePath(flagPath string, cfg config.Config) (string, error) {
+	for _, path := range []string{flagPath, os.Getenv(DB_PATH_ENV), cfg.DatabasePath} {
+		if path != "" {
+			return expandHome(path)
 		}
+	}
 
-		// Test the connection
-		if err := instance.Ping(); err != nil {
-			initErr = err
-			return
-		}
-	})
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	return filepath.Join(dir, DB_FILE_NAME), nil
+}
+
+// NewDatabase opens the database at path, creating its parent directories
+// on first run.
+func NewDatabase(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	// Foreign keys are off by default in SQLite and the pragma is per connection.
+	db, err := sql.Open(DB_DRIVER_NAME, path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	return instance, initErr
+	return db, nil
 }
 
-// GetInstance returns the existing database instance without trying to create a new one
-func GetInstance() *sql.DB {
-	return instance
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
 }
@@ -0,0 +1,393 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+// ErrSchemaTooNew is returned when the database was written by a newer
+// version of jou than the one running.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of jou")
+
+type migration struct {
+	description string
+	up          string
+	// run, when set, is called after up inside the same transaction for
+	// data changes that cannot be written in SQL alone.
+	run func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations are applied in order and must never be edited or reordered once
+// released; the schema version stored in PRAGMA user_version is the number of
+// migrations applied so far.
+var migrations = []migration{
+	{
+		description: "create journals table",
+		up: `
+	CREATE TABLE IF NOT EXISTS journals (
+	id INTEGER NOT NULL PRIMARY KEY,
+	content TEXT NOT NULL,
+	createdAt DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+`,
+	},
+	{
+		description: "add full-text index over journal content",
+		up: `
+	CREATE VIRTUAL TABLE journals_fts USING fts5(
+	content,
+	content='journals',
+	content_rowid='id'
+	);
+
+	CREATE TRIGGER journals_fts_insert AFTER INSERT ON journals BEGIN
+	INSERT INTO journals_fts(rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER journals_fts_delete AFTER DELETE ON journals BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER journals_fts_update AFTER UPDATE ON journals BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	INSERT INTO journals_fts(rowid, content) VALUES (new.id, new.content);
+	END;
+
+	INSERT INTO journals_fts(journals_fts) VALUES ('rebuild');
+`,
+	},
+	{
+		description: "track when journals were last edited",
+		up: `
+	ALTER TABLE journals ADD COLUMN updatedAt DATETIME;
+`,
+	},
+	{
+		description: "keep earlier versions of edited journals",
+		up: `
+	CREATE TABLE journal_revisions (
+	id INTEGER NOT NULL PRIMARY KEY,
+	journalId INTEGER NOT NULL REFERENCES journals(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	createdAt DATETIME NOT NULL
+	);
+
+	CREATE INDEX journal_revisions_journalId ON journal_revisions(journalId, createdAt);
+`,
+	},
+	{
+		description: "move deleted journals to the trash instead of dropping them",
+		up: `
+	ALTER TABLE journals ADD COLUMN deletedAt DATETIME;
+
+	CREATE INDEX journals_deletedAt ON journals(deletedAt);
+`,
+	},
+	{
+		description: "add starred flag and location to journals",
+		up: `
+	ALTER TABLE journals ADD COLUMN starred INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE journals ADD COLUMN location TEXT NOT NULL DEFAULT '';
+`,
+	},
+	{
+		description: "date journals separately from when they were written",
+		up: `
+	ALTER TABLE journals ADD COLUMN entryDate DATETIME;
+	UPDATE journals SET entryDate = createdAt;
+
+	CREATE INDEX journals_entryDate ON journals(entryDate);
+`,
+	},
+	{
+		description: "tag journals",
+		up: `
+	CREATE TABLE tags (
+	id INTEGER NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+	);
+
+	CREATE TABLE journal_tags (
+	journalId INTEGER NOT NULL REFERENCES journals(id) ON DELETE CASCADE,
+	tagId INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	explicit INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (journalId, tagId)
+	);
+
+	CREATE INDEX journal_tags_tagId ON journal_tags(tagId);
+`,
+		run: backfillInlineTags,
+	},
+	{
+		description: "give journals an optional title and index it for search",
+		up: `
+	ALTER TABLE journals ADD COLUMN title TEXT NOT NULL DEFAULT '';
+
+	DROP TRIGGER journals_fts_insert;
+	DROP TRIGGER journals_fts_delete;
+	DROP TRIGGER journals_fts_update;
+	DROP TABLE journals_fts;
+
+	CREATE VIRTUAL TABLE journals_fts USING fts5(
+	content,
+	title,
+	content='journals',
+	content_rowid='id'
+	);
+
+	CREATE TRIGGER journals_fts_insert AFTER INSERT ON journals BEGIN
+	INSERT INTO journals_fts(rowid, content, title) VALUES (new.id, new.content, new.title);
+	END;
+
+	CREATE TRIGGER journals_fts_delete AFTER DELETE ON journals BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content, title) VALUES ('delete', old.id, old.content, old.title);
+	END;
+
+	CREATE TRIGGER journals_fts_update AFTER UPDATE ON journals BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content, title) VALUES ('delete', old.id, old.content, old.title);
+	INSERT INTO journals_fts(rowid, content, title) VALUES (new.id, new.content, new.title);
+	END;
+
+	INSERT INTO journals_fts(journals_fts) VALUES ('rebuild');
+`,
+	},
+	{
+		description: "allow encrypting journals with a passphrase",
+		// The single encryption row holds what is needed to derive the key
+		// again. While it exists journal text is sealed, and the full-text
+		// index is left empty so no plaintext is written to it.
+		up: `
+	CREATE TABLE encryption (
+	id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+	salt BLOB NOT NULL,
+	time INTEGER NOT NULL,
+	memory INTEGER NOT NULL,
+	threads INTEGER NOT NULL,
+	verifier TEXT NOT NULL
+	);
+
+	DROP TRIGGER journals_fts_insert;
+	DROP TRIGGER journals_fts_delete;
+	DROP TRIGGER journals_fts_update;
+
+	CREATE TRIGGER journals_fts_insert AFTER INSERT ON journals
+	WHEN NOT EXISTS (SELECT 1 FROM encryption) BEGIN
+	INSERT INTO journals_fts(rowid, content, title) VALUES (new.id, new.content, new.title);
+	END;
+
+	CREATE TRIGGER journals_fts_delete AFTER DELETE ON journals
+	WHEN NOT EXISTS (SELECT 1 FROM encryption) BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content, title) VALUES ('delete', old.id, old.content, old.title);
+	END;
+
+	CREATE TRIGGER journals_fts_update AFTER UPDATE ON journals
+	WHEN NOT EXISTS (SELECT 1 FROM encryption) BEGIN
+	INSERT INTO journals_fts(journals_fts, rowid, content, title) VALUES ('delete', old.id, old.content, old.title);
+	INSERT INTO journals_fts(rowid, content, title) VALUES (new.id, new.content, new.title);
+	END;
+`,
+	},
+	{
+		description: "autosave text being written as drafts",
+		// Drafts are kept apart from journals so they never show up in
+		// lists, search or exports. entryDate is NULL for an undated entry.
+		up: `
+	CREATE TABLE drafts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	journalId INTEGER REFERENCES journals(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	entryDate DATETIME,
+	updatedAt DATETIME NOT NULL
+	);
+`,
+	},
+	{
+		description: "store every date in UTC so dates sort by instant",
+		run:         utcDates,
+	},
+	{
+		description: "find tags by a lookup key so their names can be sealed",
+		// lookup is the tag's name, or a keyed hash of it once the journal
+		// is encrypted and the name is sealed.
+		up: `
+	ALTER TABLE tags ADD COLUMN lookup TEXT NOT NULL DEFAULT '';
+	UPDATE tags SET lookup = name;
+	CREATE UNIQUE INDEX tags_lookup ON tags(lookup);
+`,
+	},
+}
+
+// SchemaVersion is the schema version this binary expects.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// Migrate brings the database at path up to SchemaVersion. Every migration
+// runs in its own transaction together with the version bump, and a copy of
+// an existing database is written next to it before anything is changed.
+func Migrate(ctx context.Context, db *sql.DB, path string) error {
+	current, err := userVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	target := SchemaVersion()
+	if current > target {
+		return fmt.Errorf("%w (database: v%d, jou: v%d)", ErrSchemaTooNew, current, target)
+	}
+	if current == target {
+		return nil
+	}
+
+	empty, err := isEmpty(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		backup, err := backup(ctx, db, path, current)
+		if err != nil {
+			return fmt.Errorf("failed to back up database before migrating: %w", err)
+		}
+		log.Printf("Backed up database to %s", backup)
+	}
+
+	for version := current; version < target; version++ {
+		if err := apply(ctx, db, version+1, migrations[version]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, version int, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.up != "" {
+		if _, err := tx.ExecContext(ctx, m.up); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", version, m.description, err)
+		}
+	}
+	if m.run != nil {
+		if err := m.run(ctx, tx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", version, m.description, err)
+		}
+	}
+	// PRAGMA does not accept bound parameters.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("migration %d (%s) failed to record version: %w", version, m.description, err)
+	}
+	return tx.Commit()
+}
+
+// backfillInlineTags tags existing journals with the #tags in their content.
+func backfillInlineTags(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, content FROM journals")
+	if err != nil {
+		return err
+	}
+	tagged := map[int][]string{}
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if tags := domains.ExtractTags(content); len(tags) > 0 {
+			tagged[id] = tags
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, tags := range tagged {
+		for _, tag := range tags {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO tags(name) VALUES(?) ON CONFLICT(name) DO NOTHING", tag); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO journal_tags(journalId, tagId) SELECT ?, id FROM tags WHERE name = ?", id, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// datedColumns are the DATETIME columns of every table, which utcDates
+// rewrites.
+var datedColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"journals", []string{"entryDate", "createdAt", "updatedAt", "deletedAt"}},
+	{"journal_revisions", []string{"createdAt"}},
+	{"drafts", []string{"entryDate", "updatedAt"}},
+}
+
+// utcDates converts dates written in the local zone to UTC. The driver
+// stores times as text that includes the zone, so dates written in
+// different zones sorted by their wall clock rather than by instant.
+func utcDates(ctx context.Context, tx *sql.Tx) error {
+	for _, t := range datedColumns {
+		for _, column := range t.columns {
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT id, %s FROM %s WHERE %[1]s IS NOT NULL", column, t.table))
+			if err != nil {
+				return err
+			}
+			dates := map[int]time.Time{}
+			for rows.Next() {
+				var id int
+				var date time.Time
+				if err := rows.Scan(&id, &date); err != nil {
+					rows.Close()
+					return fmt.Errorf("%s %d: %w", t.table, id, err)
+				}
+				dates[id] = date
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", t.table, column)
+			for id, date := range dates {
+				if _, err := tx.ExecContext(ctx, update, date.UTC(), id); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func userVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+func isEmpty(ctx context.Context, db *sql.DB) (bool, error) {
+	var tables int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_schema WHERE type = 'table'").Scan(&tables)
+	return tables == 0, err
+}
+
+func backup(ctx context.Context, db *sql.DB, path string, version int) (string, error) {
+	dest := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// openAt returns a database in dir at the given schema version.
+func openAt(t *testing.T, dir string, version int) (*sql.DB, string) {
+	t.Helper()
+	path := filepath.Join(dir, DB_FILE_NAME)
+	db, err := NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for v := 0; v < version; v++ {
+		if err := apply(context.Background(), db, v+1, migrations[v]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, path
+}
+
+func TestMigrateOldDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, path := openAt(t, t.TempDir(), 0)
+
+	// The table jou created before it kept a schema version.
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE journals (
+		id INTEGER NOT NULL PRIMARY KEY,
+		content TEXT NOT NULL,
+		createdAt DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO journals(content) VALUES('written before migrations');`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(ctx, db, path); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	version, err := userVersion(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != SchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, SchemaVersion())
+	}
+
+	backups, err := filepath.Glob(path + ".v0-*.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("backups = %v, want one of version 0", backups)
+	}
+
+	var content string
+	if err := db.QueryRowContext(ctx, "SELECT content FROM journals").Scan(&content); err != nil {
+		t.Fatal(err)
+	}
+	if content != "written before migrations" {
+		t.Errorf("content = %q after migrating", content)
+	}
+
+	// Running it again neither changes nor backs up anything.
+	if err := Migrate(ctx, db, path); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+	if backups, _ = filepath.Glob(path + ".v*.bak"); len(backups) != 1 {
+		t.Errorf("backups = %v after migrating twice, want one", backups)
+	}
+}
+
+func TestMigrateDatesToUTC(t *testing.T) {
+	ctx := context.Background()
+	// Every table with dates exists, but dates are still in local time.
+	db, path := openAt(t, t.TempDir(), 11)
+
+	// The same evening written from two zones: Tokyo comes first by
+	// instant but last by wall clock.
+	tokyo := time.Date(2024, 1, 2, 8, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	newYork := time.Date(2024, 1, 1, 19, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	for _, j := range []struct {
+		content string
+		date    time.Time
+	}{
+		{"written in Tokyo", tokyo},
+		{"written in New York", newYork},
+	} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO journals(content, createdAt, entryDate) VALUES(?, ?, ?)", j.content, j.date, j.date); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Migrate(ctx, db, path); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT content, entryDate FROM journals ORDER BY entryDate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var contents []string
+	for rows.Next() {
+		var content string
+		var date time.Time
+		if err := rows.Scan(&content, &date); err != nil {
+			t.Fatal(err)
+		}
+		if date.Location() != time.UTC {
+			t.Errorf("%q is dated %v, want UTC", content, date)
+		}
+		contents = append(contents, content)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"written in Tokyo", "written in New York"}; !slices.Equal(contents, want) {
+		t.Errorf("journals by date = %q, want %q", contents, want)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	db, path := openAt(t, t.TempDir(), SchemaVersion())
+	if _, err := db.ExecContext(ctx, "PRAGMA user_version = 1000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(ctx, db, path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("Migrate = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestMigrateBackfillsInlineTags(t *testing.T) {
+	ctx := context.Background()
+	// Journals written before tags.
+	db, path := openAt(t, t.TempDir(), 7)
+	for _, content := range []string{"a #Walk in the park", "#work and more #walk"} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO journals(content) VALUES(?)", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Migrate(ctx, db, path); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM tags ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var tags []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"walk", "work"}; !slices.Equal(tags, want) {
+		t.Errorf("tags = %q, want the inline tags %q", tags, want)
+	}
+}
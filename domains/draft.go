@@ -0,0 +1,19 @@
+package domains
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDraftNotFound = errors.New("draft not found")
+
+// Draft is text written in the editor that has not been saved as a journal.
+// JournalId is the journal it edits, or 0 for a new entry, and EntryDate is
+// zero unless the entry was given a date.
+type Draft struct {
+	Id        int       `json:"id"`
+	JournalId int       `json:"journalId,omitempty"`
+	Content   string    `json:"content"`
+	EntryDate time.Time `json:"entryDate,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
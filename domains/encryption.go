@@ -0,0 +1,11 @@
+package domains
+
+import "errors"
+
+var (
+	ErrLocked          = errors.New("journal is locked")
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrEncrypted       = errors.New("journal is already encrypted")
+	ErrNotEncrypted    = errors.New("journal is not encrypted")
+	ErrEmptyPassphrase = errors.New("passphrase is empty")
+)
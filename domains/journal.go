@@ -1,9 +1,67 @@
 package domains
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
+var (
+	ErrJournalNotFound = errors.New("journal not found")
+	ErrFutureDate      = errors.New("entries cannot be dated in the future")
+)
+
+// Journal is a single entry. EntryDate is the day the entry is about and
+// can be backdated, while CreatedAt records when it was actually written.
+// Tags holds both the #tags written in Content and any set explicitly.
+// Title is only set when the user gave one, see DisplayTitle.
 type Journal struct {
-	Id        int       `json:"id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"createdAt"`
+	Id        int        `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	Content   string     `json:"content"`
+	Starred   bool       `json:"starred,omitempty"`
+	Location  string     `json:"location,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	EntryDate time.Time  `json:"entryDate"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// ClampEntryDate checks date can be an entry's date at now. A day that has
+// not come yet is refused, while a later time today is moved back to now.
+func ClampEntryDate(date, now time.Time) (time.Time, error) {
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	if !date.Before(tomorrow) {
+		return date, ErrFutureDate
+	}
+	if date.After(now) {
+		return now, nil
+	}
+	return date, nil
+}
+
+// DisplayTitle is the journal's title, or one derived from its content when
+// none was set.
+func (j Journal) DisplayTitle() string {
+	if j.Title != "" {
+		return j.Title
+	}
+	return DeriveTitle(j.Content)
+}
+
+// DeriveTitle uses the first Markdown heading in content, falling back to
+// its first non-empty line.
+func DeriveTitle(content string) string {
+	first := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if heading := strings.TrimLeft(line, "#"); heading != line && strings.HasPrefix(heading, " ") {
+			return strings.TrimSpace(heading)
+		}
+		if first == "" {
+			first = line
+		}
+	}
+	return first
 }
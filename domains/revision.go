@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision is an earlier version of a journal's content. CreatedAt is when
+// that version was written, not when it was replaced.
+type Revision struct {
+	Id        int       `json:"id"`
+	JournalId int       `json:"journalId"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package domains
+
+// Markers wrapped around matched terms in SearchResult.Snippet. They are
+// control characters so they never collide with journal content and can be
+// swapped for styling by whoever renders the snippet.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+type SearchResult struct {
+	Journal Journal `json:"journal"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
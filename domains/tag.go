@@ -0,0 +1,82 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+)
+
+// Tag is a label shared by one or more journals. Count and LastUsed only
+// consider journals that are not in the trash.
+type Tag struct {
+	Name     string    `json:"name"`
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// An inline tag starts with a letter or underscore so "issue #42" is not
+// mistaken for one, and a "# Heading" never matches because of the space.
+// Tags set explicitly may also start with a digit, like "2024-trip".
+var (
+	tagName   = regexp.MustCompile(`^[\p{L}\p{N}_][\p{L}\p{N}_-]*$`)
+	inlineTag = regexp.MustCompile(`(?:^|\s)#([\p{L}_][\p{L}\p{N}_-]*)`)
+)
+
+// NormalizeTag lower-cases a tag and strips a leading # or @, turning inner
+// spaces into dashes. It fails when what is left is not a valid tag name.
+func NormalizeTag(name string) (string, error) {
+	tag := strings.TrimLeft(strings.TrimSpace(name), "#@")
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	tag = strings.TrimRight(tag, "-")
+	if !tagName.MatchString(tag) {
+		return "", fmt.Errorf("%w %q", ErrInvalidTag, name)
+	}
+	return tag, nil
+}
+
+// ExtractTags returns the #tags written in content, normalized, sorted and
+// without duplicates.
+func ExtractTags(content string) []string {
+	var tags []string
+	for _, match := range inlineTag.FindAllStringSubmatch(content, -1) {
+		if tag, err := NormalizeTag(match[1]); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// RenameInlineTag rewrites every #from written in content as #to, however
+// it was capitalised.
+func RenameInlineTag(content, from, to string) string {
+	return inlineTag.ReplaceAllStringFunc(content, func(match string) string {
+		i := strings.IndexByte(match, '#')
+		if tag, err := NormalizeTag(match[i+1:]); err != nil || tag != from {
+			return match
+		}
+		return match[:i+1] + to
+	})
+}
+
+// ExtraTags are the journal's tags that are not written inline in its
+// content, i.e. the ones that were set explicitly.
+func (j Journal) ExtraTags() []string {
+	inline := ExtractTags(j.Content)
+	var extra []string
+	for _, tag := range j.Tags {
+		if !slices.Contains(inline, tag) {
+			extra = append(extra, tag)
+		}
+	}
+	return extra
+}
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const fallback = "vi"
+
+// ErrEncrypted is returned instead of opening an entry of an encrypted
+// journal, since the editor needs it in a file in plain text.
+var ErrEncrypted = errors.New("the external editor is not used for encrypted journals, it would write the entry to disk unencrypted")
+
+// Command builds the command that opens path in the user's editor: $VISUAL,
+// then $EDITOR, then vi. Both variables may carry arguments, e.g.
+// "code --wait". A variable holding only spaces counts as unset.
+func Command(path string) (*exec.Cmd, error) {
+	args := strings.Fields(os.Getenv("VISUAL"))
+	if len(args) == 0 {
+		args = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(args) == 0 {
+		args = []string{fallback}
+	}
+
+	bin, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("editor %q not found: %w", args[0], err)
+	}
+	return exec.Command(bin, append(args[1:], path)...), nil
+}
+
+// TempFile writes content to a new private markdown file for editing,
+// ending it with a newline like any other text file.
+func TempFile(content string) (string, error) {
+	f, err := os.CreateTemp("", "jou-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if _, err := f.WriteString(content); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// ReadBack returns what the editor left in path and removes the file. The
+// trailing newline most editors add is dropped.
+func ReadBack(path string) (string, error) {
+	defer os.Remove(path)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\n"), nil
+}
+
+// Edit opens content in the editor attached to the given terminal streams
+// and returns the edited text.
+func Edit(content string, stdin io.Reader, stdout, stderr io.Writer) (string, error) {
+	path, err := TempFile(content)
+	if err != nil {
+		return "", err
+	}
+
+	cmd, err := Command(path)
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("editor exited: %w", err)
+	}
+
+	return ReadBack(path)
+}
@@ -0,0 +1,173 @@
+package exchange
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+var (
+	// Day One escapes Markdown punctuation in plain text, e.g. "3\. done".
+	dayOneEscape = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!>|~])")
+	// Attachments are referenced as ![](dayone-moment://ID), with a kind
+	// segment for anything other than photos.
+	dayOneMoment = regexp.MustCompile(`!\[[^\]]*\]\(dayone-moment:/+(?:(video|audio|pdfAttachment)/)?[A-Za-z0-9-]+\)`)
+)
+
+type dayOneExport struct {
+	Entries []json.RawMessage `json:"entries"`
+}
+
+type dayOneEntry struct {
+	UUID         string          `json:"uuid"`
+	CreationDate time.Time       `json:"creationDate"`
+	TimeZone     string          `json:"timeZone"`
+	Text         string          `json:"text"`
+	Tags         []string        `json:"tags"`
+	Starred      bool            `json:"starred"`
+	Location     *dayOneLocation `json:"location"`
+}
+
+type dayOneLocation struct {
+	PlaceName          string `json:"placeName"`
+	LocalityName       string `json:"localityName"`
+	AdministrativeArea string `json:"administrativeArea"`
+	Country            string `json:"country"`
+}
+
+// ImportDayOne imports a Day One JSON export, either the Journal.json file
+// itself or the zip archive Day One produces, which may hold several
+// journals. Each entry is reported on its own; a bad entry does not stop the
+// rest from importing.
+func ImportDayOne(ctx context.Context, im *Importer, file string) (ImportReport, error) {
+	if strings.EqualFold(path.Ext(file), ".zip") {
+		return importDayOneZip(ctx, im, file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer f.Close()
+
+	results, err := importDayOneJSON(ctx, im, path.Base(file), f)
+	return im.Report(results), err
+}
+
+func importDayOneZip(ctx context.Context, im *Importer, file string) (ImportReport, error) {
+	archive, err := zip.OpenReader(file)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer archive.Close()
+
+	var results []ImportResult
+	found := false
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || !strings.EqualFold(path.Ext(entry.Name), ".json") {
+			continue
+		}
+		found = true
+
+		r, err := entry.Open()
+		if err != nil {
+			return im.Report(results), err
+		}
+		journalResults, err := importDayOneJSON(ctx, im, entry.Name, r)
+		r.Close()
+		results = append(results, journalResults...)
+		if err != nil {
+			return im.Report(results), err
+		}
+	}
+
+	if !found {
+		return im.Report(results), fmt.Errorf("no Day One journal (.json) found in %s", file)
+	}
+	return im.Report(results), nil
+}
+
+func importDayOneJSON(ctx context.Context, im *Importer, name string, r io.Reader) ([]ImportResult, error) {
+	var export dayOneExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%s is not a Day One export: %w", name, err)
+	}
+
+	results := make([]ImportResult, 0, len(export.Entries))
+	for i, raw := range export.Entries {
+		source := fmt.Sprintf("%s entry %d", name, i+1)
+
+		// Name the entry by its uuid even if the rest of it is malformed.
+		var id struct {
+			UUID string `json:"uuid"`
+		}
+		if json.Unmarshal(raw, &id) == nil && id.UUID != "" {
+			source += " (" + id.UUID + ")"
+		}
+
+		var entry dayOneEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			results = append(results, ImportResult{Source: source, Status: Failed, Err: err})
+			continue
+		}
+		if entry.CreationDate.IsZero() {
+			results = append(results, ImportResult{Source: source, Status: Failed, Err: fmt.Errorf("entry has no creationDate")})
+			continue
+		}
+
+		results = append(results, im.Import(ctx, source, entry.journal()))
+	}
+	return results, nil
+}
+
+func (e dayOneEntry) journal() domains.Journal {
+	date := e.CreationDate
+	if loc, err := time.LoadLocation(e.TimeZone); e.TimeZone != "" && err == nil {
+		date = date.In(loc)
+	}
+
+	content := dayOneMoment.ReplaceAllStringFunc(e.Text, func(moment string) string {
+		kind := dayOneMoment.FindStringSubmatch(moment)[1]
+		switch kind {
+		case "":
+			return "[photo]"
+		case "pdfAttachment":
+			return "[pdf]"
+		default:
+			return "[" + kind + "]"
+		}
+	})
+	content = dayOneEscape.ReplaceAllString(content, "$1")
+
+	return domains.Journal{
+		Content:   content,
+		Tags:      e.Tags,
+		Starred:   e.Starred,
+		Location:  e.Location.String(),
+		EntryDate: date,
+	}
+}
+
+// String joins the named parts of a location, most specific first.
+func (l *dayOneLocation) String() string {
+	if l == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, part := range []string{l.PlaceName, l.LocalityName, l.AdministrativeArea, l.Country} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
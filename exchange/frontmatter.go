@@ -0,0 +1,95 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter holds the fields jou understands from YAML front matter.
+// Other tools name the entry date differently, so a few spellings are
+// accepted.
+type frontMatter struct {
+	CreatedAt string `yaml:"createdAt"`
+	Created   string `yaml:"created"`
+	Date      string `yaml:"date"`
+	Title     string `yaml:"title"`
+	Tags      tags   `yaml:"tags"`
+	Starred   bool   `yaml:"starred"`
+	Location  string `yaml:"location"`
+}
+
+// tags accepts both a YAML list and a single comma or space separated string.
+type tags []string
+
+func (t *tags) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*t = list
+		return nil
+	}
+
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	*t = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	return nil
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func (fm frontMatter) date() (time.Time, bool, error) {
+	for _, value := range []string{fm.Date, fm.CreatedAt, fm.Created} {
+		if value == "" {
+			continue
+		}
+		date, err := parseDate(value)
+		return date, err == nil, err
+	}
+	return time.Time{}, false, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// splitFrontMatter separates a leading --- delimited YAML block from the
+// document body. Documents without one are returned unchanged.
+func splitFrontMatter(doc string) (frontMatter, string, error) {
+	var fm frontMatter
+
+	doc = strings.TrimPrefix(doc, "\ufeff")
+	rest, ok := strings.CutPrefix(doc, frontMatterDelimiter+"\n")
+	if !ok {
+		return fm, doc, nil
+	}
+
+	end := strings.Index(rest, "\n"+frontMatterDelimiter)
+	if end < 0 {
+		return fm, doc, nil
+	}
+	header := rest[:end]
+	body := rest[end+len(frontMatterDelimiter)+1:]
+
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return fm, doc, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, body, nil
+}
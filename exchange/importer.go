@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/ports"
+)
+
+type ImportStatus string
+
+const (
+	Imported  ImportStatus = "imported"
+	Duplicate ImportStatus = "duplicate"
+	Failed    ImportStatus = "failed"
+)
+
+// ImportResult is the outcome for a single entry. Source says where it came
+// from, e.g. a file name.
+type ImportResult struct {
+	Source    string
+	Status    ImportStatus
+	Id        int
+	EntryDate time.Time
+	Err       error
+}
+
+type ImportReport struct {
+	DryRun  bool
+	Results []ImportResult
+}
+
+func (r ImportReport) Count(status ImportStatus) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Importer creates journals from other formats, skipping any whose content
+// is already in the journal. In dry-run mode nothing is written.
+type Importer struct {
+	service ports.JournalService
+	dryRun  bool
+	hashes  map[string]bool
+}
+
+// NewImporter remembers the content hash of every existing journal so that
+// importing the same data twice does not duplicate it.
+func NewImporter(ctx context.Context, service ports.JournalService, dryRun bool) (*Importer, error) {
+	im := &Importer{
+		service: service,
+		dryRun:  dryRun,
+		hashes:  map[string]bool{},
+	}
+	err := service.Each(ctx, func(journal domains.Journal) error {
+		im.hashes[entryHash(journal)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing journals: %w", err)
+	}
+	return im, nil
+}
+
+// Import creates journal unless an entry with the same content exists.
+func (im *Importer) Import(ctx context.Context, source string, journal domains.Journal) ImportResult {
+	result := ImportResult{Source: source, EntryDate: journal.EntryDate}
+
+	journal.Content = strings.TrimSpace(journal.Content)
+	if journal.Content == "" {
+		result.Status, result.Err = Failed, fmt.Errorf("entry is empty")
+		return result
+	}
+
+	// Check tags here too so a dry run reports the ones Create would reject.
+	for _, tag := range journal.Tags {
+		if _, err := domains.NormalizeTag(tag); err != nil {
+			result.Status, result.Err = Failed, err
+			return result
+		}
+	}
+
+	hash := entryHash(journal)
+	if im.hashes[hash] {
+		result.Status = Duplicate
+		return result
+	}
+
+	if !im.dryRun {
+		id, err := im.service.Create(ctx, journal)
+		if err != nil {
+			result.Status, result.Err = Failed, err
+			return result
+		}
+		result.Id = id
+	}
+
+	im.hashes[hash] = true
+	result.Status = Imported
+	return result
+}
+
+func (im *Importer) Report(results []ImportResult) ImportReport {
+	return ImportReport{DryRun: im.dryRun, Results: results}
+}
+
+// entryHash identifies an entry by its text regardless of surrounding
+// whitespace. Formats such as jrnl keep the title and tags in the text, so an
+// explicit title the content does not start with counts as its first line,
+// and tags at the end of that line are left out.
+func entryHash(journal domains.Journal) string {
+	text := strings.TrimSpace(journal.Content)
+	first, rest, _ := strings.Cut(text, "\n")
+	if journal.Title != "" && strings.TrimSpace(first) != journal.Title {
+		first, rest = journal.Title, text
+	}
+	first, _ = cutTrailingTags(first)
+	sum := sha256.Sum256([]byte(strings.TrimSpace(first + "\n" + rest)))
+	return hex.EncodeToString(sum[:])
+}
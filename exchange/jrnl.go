@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/ports"
+)
+
+// JrnlTimeFormat is jrnl's default timestamp format, "%Y-%m-%d %H:%M".
+const JrnlTimeFormat = "2006-01-02 15:04"
+
+// jrnl writes starred entries with a trailing " *" on the title line.
+const jrnlStar = " *"
+
+var (
+	jrnlHeader      = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} [^\]]+)\] ?(.*)$`)
+	jrnlTag         = regexp.MustCompile(`(^|\s)@(\w[\w-]*)`)
+	trailingTag     = regexp.MustCompile(`(^|\s+)[#@](\w[\w-]*)$`)
+	hashtag         = regexp.MustCompile(`(^|\s)#(\w[\w-]*)`)
+	jrnlTimeFormats = []string{JrnlTimeFormat, "2006-01-02 03:04 PM", "2006-01-02 15:04:05"}
+)
+
+// ImportJrnl imports a jrnl plain-text journal, where every entry starts
+// with a "[YYYY-MM-DD HH:MM] Title" line. jrnl's @tags become jou #tags, and
+// the ones at the end of the title line become the entry's explicit tags.
+func ImportJrnl(ctx context.Context, im *Importer, name string, r io.Reader) (ImportReport, error) {
+	var results []ImportResult
+	var started bool
+	var header string
+	var date time.Time
+	var body []string
+	entry := 0
+
+	flush := func() {
+		if !started {
+			return
+		}
+		entry++
+		journal := parseJrnlEntry(header, body)
+		journal.EntryDate = date
+		results = append(results, im.Import(ctx, fmt.Sprintf("%s entry %d", name, entry), journal))
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		match := jrnlHeader.FindStringSubmatch(line)
+		if match == nil {
+			if !started && strings.TrimSpace(line) != "" {
+				return im.Report(results), fmt.Errorf("%s:%d: expected an entry to start with [YYYY-MM-DD HH:MM]", name, lineNo)
+			}
+			body = append(body, line)
+			continue
+		}
+
+		parsed, err := parseJrnlTime(match[1])
+		if err != nil {
+			// Not a timestamp after all, keep it as text.
+			body = append(body, line)
+			continue
+		}
+
+		flush()
+		started, header, date, body = true, match[2], parsed, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return im.Report(results), err
+	}
+	flush()
+
+	return im.Report(results), nil
+}
+
+func parseJrnlTime(value string) (time.Time, error) {
+	for _, layout := range jrnlTimeFormats {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised jrnl timestamp %q", value)
+}
+
+// parseJrnlEntry turns a title line and the lines after it into a journal.
+// jrnl has no titles apart from the text, so the title line is the first
+// line of the content, less the tags at its end.
+func parseJrnlEntry(title string, body []string) domains.Journal {
+	var journal domains.Journal
+	if strings.HasSuffix(title, jrnlStar) {
+		title = strings.TrimSuffix(title, jrnlStar)
+		journal.Starred = true
+	}
+	title, journal.Tags = cutTrailingTags(title)
+
+	content := strings.TrimSpace(title + "\n" + strings.Join(body, "\n"))
+	journal.Content = jrnlTag.ReplaceAllString(content, "$1#$2")
+	return journal
+}
+
+// ExportJrnl writes every journal, oldest first, in jrnl's plain-text
+// format and returns how many were written.
+func ExportJrnl(ctx context.Context, service ports.JournalService, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	count := 0
+
+	err := service.Each(ctx, func(journal domains.Journal) error {
+		content := hashtag.ReplaceAllString(strings.TrimSpace(journal.Content), "$1@$2")
+		// jrnl's title is the first line, so an explicit title goes in front
+		// unless the content already starts with it.
+		title, body, _ := strings.Cut(content, "\n")
+		if first, _, _ := strings.Cut(strings.TrimSpace(journal.Content), "\n"); journal.Title != "" && strings.TrimSpace(first) != journal.Title {
+			title, body = journal.Title, content
+		}
+		// jrnl only knows tags in the text, so explicit ones go at the end
+		// of the title line.
+		if extra := journal.ExtraTags(); len(extra) > 0 {
+			title += " @" + strings.Join(extra, " @")
+		}
+		if journal.Starred {
+			title += jrnlStar
+		}
+
+		if count > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "[%s] %s\n", journal.EntryDate.Local().Format(JrnlTimeFormat), title)
+		if body = strings.TrimSpace(body); body != "" {
+			bw.WriteString(body + "\n")
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+// cutTrailingTags splits the #tags or @tags at the end of line off it.
+func cutTrailingTags(line string) (string, []string) {
+	var tags []string
+	line = strings.TrimSpace(line)
+	for {
+		match := trailingTag.FindStringSubmatchIndex(line)
+		if match == nil {
+			return line, tags
+		}
+		tags = append([]string{line[match[4]:match[5]]}, tags...)
+		line = line[:match[0]]
+	}
+}
@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/ports"
+)
+
+// sampleJournals creates entries covering what the jrnl format carries and
+// returns them as stored, newest first.
+func sampleJournals(t *testing.T, service ports.JournalService) []domains.Journal {
+	t.Helper()
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	for _, journal := range []domains.Journal{
+		{Content: "Morning\nwent for a run with #fitness in mind", EntryDate: day},
+		{Title: "Meeting", Content: "notes about #work\nsecond line", EntryDate: day.AddDate(0, 0, 1)},
+		{Title: "Same", Content: "Same\nthe title is the first line", EntryDate: day.AddDate(0, 0, 2)},
+		{Content: "a good day", Starred: true, EntryDate: day.AddDate(0, 0, 3)},
+		{Title: "Trip", Content: "went to the sea", Tags: []string{"travel"}, EntryDate: day.AddDate(0, 0, 4)},
+	} {
+		if _, err := service.Create(ctx, journal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := service.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestJrnlRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newService(t)
+	want := sampleJournals(t, source)
+
+	var exported bytes.Buffer
+	if n, err := ExportJrnl(ctx, source, &exported); err != nil || n != len(want) {
+		t.Fatalf("ExportJrnl = %d, %v, want %d", n, err, len(want))
+	}
+
+	// Into the same journal every entry, titled or not, is a duplicate.
+	report, err := ImportJrnl(ctx, importer(t, source), "export", bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(Duplicate); n != len(want) {
+		t.Errorf("re-import skipped %d duplicates, want %d: %+v", n, len(want), report.Results)
+	}
+
+	target := newService(t)
+	report, err = ImportJrnl(ctx, importer(t, target), "export", bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(Imported); n != len(want) {
+		t.Fatalf("imported %d entries, want %d: %+v", n, len(want), report.Results)
+	}
+	got, err := target.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if got[i].DisplayTitle() != want[i].DisplayTitle() || !got[i].EntryDate.Equal(want[i].EntryDate) ||
+			got[i].Starred != want[i].Starred || !slices.Equal(got[i].Tags, want[i].Tags) {
+			t.Errorf("imported %+v, want %+v", got[i], want[i])
+		}
+	}
+
+	// Exporting what was imported gives the same file.
+	var again bytes.Buffer
+	if _, err := ExportJrnl(ctx, target, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != exported.String() {
+		t.Errorf("second export differs:\n%s\nwant:\n%s", again.String(), exported.String())
+	}
+}
+
+func TestImportJrnl(t *testing.T) {
+	ctx := context.Background()
+	service := newService(t)
+	journal := `
+[2024-03-01 09:30 PM] Dinner with @family at home @food *
+[not a date] stays in the body
+
+[2024-03-02 08:00] Coffee
+[2024-03-03 07:00]
+a header without a title
+`
+	report, err := ImportJrnl(ctx, importer(t, service), "journal.txt", strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(Imported); n != 3 {
+		t.Fatalf("imported %d entries, want 3: %+v", n, report.Results)
+	}
+
+	got, err := service.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domains.Journal{
+		{Content: "a header without a title", EntryDate: time.Date(2024, 3, 3, 7, 0, 0, 0, time.Local)},
+		{Content: "Coffee", EntryDate: time.Date(2024, 3, 2, 8, 0, 0, 0, time.Local)},
+		{
+			Content:   "Dinner with #family at home\n[not a date] stays in the body",
+			Tags:      []string{"family", "food"},
+			Starred:   true,
+			EntryDate: time.Date(2024, 3, 1, 21, 30, 0, 0, time.Local),
+		},
+	}
+	for i := range want {
+		if got[i].Content != want[i].Content || !got[i].EntryDate.Equal(want[i].EntryDate) ||
+			got[i].Starred != want[i].Starred || !slices.Equal(got[i].Tags, want[i].Tags) {
+			t.Errorf("imported %+v, want %+v", got[i], want[i])
+		}
+	}
+
+	if _, err := ImportJrnl(ctx, importer(t, service), "notes.txt", strings.NewReader("no header\n")); err == nil {
+		t.Error("ImportJrnl accepted text without an entry header")
+	}
+}
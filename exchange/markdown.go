@@ -0,0 +1,237 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/ports"
+)
+
+const frontMatterDelimiter = "---"
+
+var filenameDate = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// exportedPath matches the paths MarkdownPath makes, relative to the export
+// directory and with forward slashes.
+var exportedPath = regexp.MustCompile(`^\d{4}/\d{2}/\d{4}-\d{2}-\d{2}-\d+\.md$`)
+
+type ExportReport struct {
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+// ExportMarkdown writes every journal to dir as YYYY/MM/YYYY-MM-DD-<id>.md
+// with YAML front matter. Files whose content would not change are left
+// alone, so exporting into the same directory again is cheap and only
+// touches entries edited since. The directory mirrors the journal: exported
+// files of deleted entries, or left at an old date, are removed.
+func ExportMarkdown(ctx context.Context, service ports.JournalService, dir string) (ExportReport, error) {
+	var report ExportReport
+
+	stale, err := exportedFiles(dir)
+	if err != nil {
+		return report, err
+	}
+
+	err = service.Each(ctx, func(journal domains.Journal) error {
+		path := filepath.Join(dir, MarkdownPath(journal))
+		content := MarshalMarkdown(journal)
+		delete(stale, path)
+
+		existing, err := os.ReadFile(path)
+		if err == nil && bytes.Equal(existing, content) {
+			report.Unchanged++
+			return nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		report.Written++
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for path := range stale {
+		if err := os.Remove(path); err != nil {
+			return report, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		report.Removed++
+		// Drop the month and year directories once they are empty.
+		month := filepath.Dir(path)
+		if os.Remove(month) == nil {
+			os.Remove(filepath.Dir(month))
+		}
+	}
+	return report, nil
+}
+
+// exportedFiles returns the set of files in dir that an earlier export
+// wrote. Anything else in dir is not touched.
+func exportedFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && exportedPath.MatchString(filepath.ToSlash(rel)) {
+			files[path] = true
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return files, nil
+	}
+	return files, err
+}
+
+// MarkdownPath is where a journal lives inside an export directory.
+func MarkdownPath(journal domains.Journal) string {
+	date := journal.EntryDate
+	return filepath.Join(
+		date.Format("2006"),
+		date.Format("01"),
+		fmt.Sprintf("%s-%d.md", date.Format("2006-01-02"), journal.Id),
+	)
+}
+
+// MarshalMarkdown renders a journal as a Markdown document with YAML front
+// matter.
+func MarshalMarkdown(journal domains.Journal) []byte {
+	var b strings.Builder
+	b.WriteString(frontMatterDelimiter + "\n")
+	b.WriteString("id: " + strconv.Itoa(journal.Id) + "\n")
+	if journal.Title != "" {
+		b.WriteString("title: " + strconv.Quote(journal.Title) + "\n")
+	}
+	b.WriteString("date: " + journal.EntryDate.Format(time.RFC3339) + "\n")
+	b.WriteString("createdAt: " + journal.CreatedAt.Format(time.RFC3339) + "\n")
+	if journal.UpdatedAt != nil {
+		b.WriteString("updatedAt: " + journal.UpdatedAt.Format(time.RFC3339) + "\n")
+	}
+	if journal.Starred {
+		b.WriteString("starred: true\n")
+	}
+	if journal.Location != "" {
+		b.WriteString("location: " + strconv.Quote(journal.Location) + "\n")
+	}
+	// Inline #tags are already in the body, only the explicit ones go here.
+	var tags []string
+	for _, tag := range journal.ExtraTags() {
+		tags = append(tags, strconv.Quote(tag))
+	}
+	b.WriteString("tags: [" + strings.Join(tags, ", ") + "]\n")
+	b.WriteString(frontMatterDelimiter + "\n\n")
+	b.WriteString(strings.TrimRight(journal.Content, "\n") + "\n")
+	return []byte(b.String())
+}
+
+// ImportMarkdown imports a Markdown file, or every Markdown file below a
+// directory. An entry is dated by its front matter, else by a YYYY-MM-DD date
+// in its file name, else by the file's modification time.
+func ImportMarkdown(ctx context.Context, im *Importer, path string) (ImportReport, error) {
+	files, err := markdownFiles(path)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	var results []ImportResult
+	for _, file := range files {
+		journal, err := readMarkdown(file)
+		if err != nil {
+			results = append(results, ImportResult{Source: file, Status: Failed, Err: err})
+			continue
+		}
+		results = append(results, im.Import(ctx, file, journal))
+	}
+	return im.Report(results), nil
+}
+
+func markdownFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".md", ".markdown":
+			if !d.IsDir() {
+				files = append(files, file)
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func readMarkdown(path string) (domains.Journal, error) {
+	var journal domains.Journal
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return journal, err
+	}
+	fm, body, err := splitFrontMatter(strings.ReplaceAll(string(b), "\r\n", "\n"))
+	if err != nil {
+		return journal, err
+	}
+
+	date, ok, err := fm.date()
+	if err != nil {
+		return journal, err
+	}
+	if !ok {
+		if match := filenameDate.FindString(filepath.Base(path)); match != "" {
+			date, err = time.ParseInLocation("2006-01-02", match, time.Local)
+			ok = err == nil
+		}
+	}
+	if !ok {
+		info, err := os.Stat(path)
+		if err != nil {
+			return journal, err
+		}
+		date = info.ModTime()
+	}
+
+	journal.Title = fm.Title
+	journal.Content = strings.TrimSpace(body)
+	journal.Tags = fm.Tags
+	journal.EntryDate = date
+	journal.Starred = fm.Starred
+	journal.Location = fm.Location
+	return journal, nil
+}
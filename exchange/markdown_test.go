@@ -0,0 +1,191 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cheersmas/jou/database"
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/ports"
+	"github.com/cheersmas/jou/repositories"
+	"github.com/cheersmas/jou/services"
+)
+
+// newService returns a service over a fresh database.
+func newService(t *testing.T) ports.JournalService {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), database.DB_FILE_NAME)
+	db, err := database.NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(ctx, db, path); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repositories.NewJournalRepository(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewJournalService(repo)
+}
+
+func TestExportMarkdown(t *testing.T) {
+	ctx := context.Background()
+	service := newService(t)
+	for _, content := range []string{"first entry", "second entry\n\nwith a paragraph"} {
+		if _, err := service.Create(ctx, domains.Journal{Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+
+	report, err := ExportMarkdown(ctx, service, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Written != 2 || report.Unchanged != 0 {
+		t.Errorf("first export = %+v, want 2 written", report)
+	}
+
+	journals, err := service.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, journal := range journals {
+		got, err := os.ReadFile(filepath.Join(dir, MarkdownPath(journal)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := MarshalMarkdown(journal); !bytes.Equal(got, want) {
+			t.Errorf("%s =\n%s\nwant:\n%s", MarkdownPath(journal), got, want)
+		}
+	}
+
+	// Only what changed since is written again.
+	if _, err := service.Update(ctx, journals[0].Id, "edited"); err != nil {
+		t.Fatal(err)
+	}
+	report, err = ExportMarkdown(ctx, service, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Written != 1 || report.Unchanged != 1 {
+		t.Errorf("second export = %+v, want 1 written and 1 unchanged", report)
+	}
+}
+
+func importer(t *testing.T, service ports.JournalService) *Importer {
+	t.Helper()
+	im, err := NewImporter(context.Background(), service, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return im
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newService(t)
+	day := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	for _, journal := range []domains.Journal{
+		{Content: "first entry", EntryDate: day},
+		{Content: "second entry\n\nwith #tags in it", Starred: true, EntryDate: day.AddDate(0, 0, 1)},
+		{Title: "Lisbon", Content: "trams and #food", Location: "Lisbon, Portugal", Tags: []string{"travel"}, EntryDate: day.AddDate(0, -1, 0)},
+	} {
+		if _, err := source.Create(ctx, journal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := source.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if _, err := ExportMarkdown(ctx, source, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing our own export again only finds duplicates.
+	report, err := ImportMarkdown(ctx, importer(t, source), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(Duplicate); n != len(want) {
+		t.Errorf("re-import skipped %d duplicates, want %d: %+v", n, len(want), report.Results)
+	}
+
+	target := newService(t)
+	report, err = ImportMarkdown(ctx, importer(t, target), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(Imported); n != len(want) {
+		t.Fatalf("imported %d entries, want %d: %+v", n, len(want), report.Results)
+	}
+	got, err := target.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if got[i].Title != want[i].Title || got[i].Content != want[i].Content || !got[i].EntryDate.Equal(want[i].EntryDate) ||
+			got[i].Starred != want[i].Starred || got[i].Location != want[i].Location || !slices.Equal(got[i].Tags, want[i].Tags) {
+			t.Errorf("imported %+v, want %+v", got[i], want[i])
+		}
+	}
+
+	// The export directory mirrors the journal.
+	if _, err := source.SetEntryDate(ctx, want[0].Id, want[0].EntryDate.AddDate(0, -1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Delete(ctx, want[1].Id); err != nil {
+		t.Fatal(err)
+	}
+	export, err := ExportMarkdown(ctx, source, dir)
+	if err != nil || export.Written != 1 || export.Removed != 2 {
+		t.Errorf("export after moving and deleting = %+v, %v, want 1 written and 2 removed", export, err)
+	}
+	for _, journal := range want[:2] {
+		if _, err := os.Stat(filepath.Join(dir, MarkdownPath(journal))); !os.IsNotExist(err) {
+			t.Errorf("%s is still there", MarkdownPath(journal))
+		}
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"front-matter.md": "---\ndate: 2024-01-31 21:00\ntags: work, ideas\n---\n\nplans with #work\n",
+		"2023-12-24.md":   "christmas eve\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file    string
+		content string
+		tags    []string
+		date    time.Time
+	}{
+		{"front-matter.md", "plans with #work", []string{"work", "ideas"}, time.Date(2024, 1, 31, 21, 0, 0, 0, time.Local)},
+		{"2023-12-24.md", "christmas eve", nil, time.Date(2023, 12, 24, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		journal, err := readMarkdown(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if journal.Content != tt.content || !slices.Equal(journal.Tags, tt.tags) || !journal.EntryDate.Equal(tt.date) {
+			t.Errorf("%s = %q %q on %v, want %q %q on %v", tt.file, journal.Content, journal.Tags, journal.EntryDate, tt.content, tt.tags, tt.date)
+		}
+	}
+}
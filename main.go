@@ -2,28 +2,72 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/cheersmas/jou/app"
+	"github.com/cheersmas/jou/cli"
+	"github.com/cheersmas/jou/config"
 	"github.com/cheersmas/jou/database"
 	"github.com/cheersmas/jou/repositories"
 	"github.com/cheersmas/jou/services"
 )
 
 func main() {
+	dbPath := flag.String("db", "", "path to the journal database")
+	flag.Usage = func() {
+		cli.New(context.Background(), nil).Usage(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	ctx := context.Background()
-	db, err := database.NewDatabase()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	path, err := database.ResolvePath(*dbPath, cfg)
+	if err != nil {
+		log.Fatalf("Failed to resolve database path: %v", err)
+	}
+
+	db, err := database.NewDatabase(path)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
-		panic(err)
 	}
 	defer db.Close()
 
+	if err := database.Migrate(ctx, db, path); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	journalRepo, err := repositories.NewJournalRepository(ctx, db)
 	if err != nil {
 		log.Fatalf("Failed to initialize journal: %v", err)
 	}
 	journalService := services.NewJournalService(journalRepo)
 
-	app.Root(ctx, journalService)
+	if cfg.TrashRetention > 0 {
+		if _, err := journalService.PurgeExpired(ctx, cfg.TrashRetention); err != nil {
+			log.Printf("Failed to empty the trash: %v", err)
+		}
+	}
+
+	if flag.NArg() > 0 {
+		if err := cli.New(ctx, journalService).Run(flag.Args()); err != nil {
+			if !errors.Is(err, cli.ErrUsage) {
+				fmt.Fprintf(os.Stderr, "jou: %v\n", err)
+			}
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	app.Root(ctx, journalService, cfg)
 }
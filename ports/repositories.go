@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/cheersmas/jou/domains"
 )
@@ -10,6 +11,32 @@ type JournalRepository interface {
 	Create(ctx context.Context, content domains.Journal) (int, error)
 	Read(ctx context.Context, journalId int) (domains.Journal, error)
 	Update(ctx context.Context, id int, content string) (int, error)
+	SetEntryDate(ctx context.Context, id int, date time.Time) (int, error)
+	SetTitle(ctx context.Context, id int, title string) (int, error)
 	Delete(ctx context.Context, id int) (int, error)
 	ListAll(ctx context.Context) ([]domains.Journal, error)
+	Each(ctx context.Context, fn func(domains.Journal) error) error
+	ListDeleted(ctx context.Context) ([]domains.Journal, error)
+	Restore(ctx context.Context, id int) (int, error)
+	Purge(ctx context.Context, id int) (int, error)
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error)
+	Search(ctx context.Context, query string) ([]domains.SearchResult, error)
+	SetTags(ctx context.Context, id int, tags []string) (int, error)
+	ListByTag(ctx context.Context, tag string) ([]domains.Journal, error)
+	ListTags(ctx context.Context) ([]domains.Tag, error)
+	RenameTag(ctx context.Context, from, to string, merge bool) (int, error)
+	ReadRevision(ctx context.Context, revisionId int) (domains.Revision, error)
+	ListRevisions(ctx context.Context, journalId int) ([]domains.Revision, error)
+	SaveDraft(ctx context.Context, draft domains.Draft) (int, error)
+	ListDrafts(ctx context.Context) ([]domains.Draft, error)
+	TouchDraft(ctx context.Context, id int) error
+	DeleteDraft(ctx context.Context, id int) error
+	PublishDraft(ctx context.Context, draft domains.Draft) (int, error)
+	Encrypted() bool
+	Locked() bool
+	Unlock(ctx context.Context, passphrase string) error
+	Lock()
+	Encrypt(ctx context.Context, passphrase string) error
+	Decrypt(ctx context.Context) error
+	ChangePassphrase(ctx context.Context, passphrase string, progress func(done, total int)) error
 }
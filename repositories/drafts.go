@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+// SaveDraft inserts a draft, or overwrites it when it has an id, and
+// returns its id. A draft that was discarded in the meantime is saved again
+// under a new id rather than lost.
+func (jr *journalRepository) SaveDraft(ctx context.Context, draft domains.Draft) (int, error) {
+	sealed, err := jr.seal(draft.Content)
+	if err != nil {
+		return -1, err
+	}
+	var entryDate sql.NullTime
+	if !draft.EntryDate.IsZero() {
+		entryDate = sql.NullTime{Time: storedTime(draft.EntryDate), Valid: true}
+	}
+	var journalId sql.NullInt64
+	if draft.JournalId != 0 {
+		journalId = sql.NullInt64{Int64: int64(draft.JournalId), Valid: true}
+	}
+	now := storedTime(time.Now())
+
+	if draft.Id != 0 {
+		res, err := jr.updateDraftQuery.ExecContext(ctx, sealed, entryDate, now, draft.Id)
+		if err != nil {
+			log.Printf("ERROR: failed to save draft %d: %v", draft.Id, err)
+			return -1, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n > 0 {
+			return draft.Id, err
+		}
+	}
+
+	res, err := jr.insertDraftQuery.ExecContext(ctx, journalId, sealed, entryDate, now)
+	if err != nil {
+		log.Printf("ERROR: failed to save draft: %v", err)
+		return -1, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// ListDrafts returns every draft, most recently saved first.
+func (jr *journalRepository) ListDrafts(ctx context.Context) ([]domains.Draft, error) {
+	rows, err := jr.listDraftsQuery.QueryContext(ctx)
+	if err != nil {
+		log.Printf("ERROR: failed to query drafts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drafts []domains.Draft
+	for rows.Next() {
+		var draft domains.Draft
+		var journalId sql.NullInt64
+		var entryDate sql.NullTime
+		if err := rows.Scan(&draft.Id, &journalId, &draft.Content, &entryDate, &draft.UpdatedAt); err != nil {
+			log.Printf("ERROR: failed to scan draft row: %v", err)
+			return nil, err
+		}
+		draft.JournalId = int(journalId.Int64)
+		if entryDate.Valid {
+			draft.EntryDate = entryDate.Time.Local()
+		}
+		draft.UpdatedAt = draft.UpdatedAt.Local()
+		if draft.Content, err = jr.open(draft.Content); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("ERROR: error after scanning draft rows: %v", err)
+		return nil, err
+	}
+
+	return drafts, nil
+}
+
+// TouchDraft marks a draft as saved now without changing it. It needs no
+// key, so it works while an encrypted journal is locked.
+func (jr *journalRepository) TouchDraft(ctx context.Context, id int) error {
+	_, err := jr.touchDraftQuery.ExecContext(ctx, storedTime(time.Now()), id)
+	return err
+}
+
+func (jr *journalRepository) DeleteDraft(ctx context.Context, id int) error {
+	return jr.deleteDraft(ctx, jr.deleteDraftQuery, id)
+}
+
+// PublishDraft saves a draft as the journal it edits, or as a new one, and
+// deletes it, all in one transaction so the draft is never lost nor left
+// behind once saved. It returns the id of the journal.
+func (jr *journalRepository) PublishDraft(ctx context.Context, draft domains.Draft) (int, error) {
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	id := draft.JournalId
+	if id == 0 {
+		if id, err = jr.create(ctx, tx, domains.Journal{Content: draft.Content, EntryDate: draft.EntryDate}); err != nil {
+			return -1, err
+		}
+	} else {
+		if err := jr.update(ctx, tx, id, draft.Content); err != nil {
+			return -1, err
+		}
+		if !draft.EntryDate.IsZero() {
+			if _, err := tx.StmtContext(ctx, jr.entryDateQuery).ExecContext(ctx, storedTime(draft.EntryDate), id); err != nil {
+				return -1, err
+			}
+		}
+	}
+
+	if err := jr.deleteDraft(ctx, tx.StmtContext(ctx, jr.deleteDraftQuery), draft.Id); err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+func (jr *journalRepository) deleteDraft(ctx context.Context, stmt *sql.Stmt, id int) error {
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: id %d", domains.ErrDraftNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,527 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/vault"
+)
+
+// Encrypted reports whether journal text is sealed with a passphrase.
+func (jr *journalRepository) Encrypted() bool {
+	jr.mu.RLock()
+	defer jr.mu.RUnlock()
+	return jr.encrypted
+}
+
+// Locked reports whether the journal is encrypted and has not been unlocked.
+func (jr *journalRepository) Locked() bool {
+	jr.mu.RLock()
+	defer jr.mu.RUnlock()
+	return jr.encrypted && jr.key == nil
+}
+
+// Unlock derives the key from passphrase and keeps it in memory until Lock
+// is called or the program exits.
+func (jr *journalRepository) Unlock(ctx context.Context, passphrase string) error {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if !jr.encrypted {
+		return domains.ErrNotEncrypted
+	}
+
+	var params vault.Params
+	var verifier string
+	if err := jr.encryptionQuery.QueryRowContext(ctx).Scan(&params.Salt, &params.Time, &params.Memory, &params.Threads, &verifier); err != nil {
+		log.Printf("ERROR: failed to read encryption settings: %v", err)
+		return err
+	}
+
+	key, err := vault.DeriveKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+	if err := key.Verify(verifier); err != nil {
+		return domains.ErrWrongPassphrase
+	}
+	if err := jr.sealPlainTags(ctx, key); err != nil {
+		log.Printf("ERROR: failed to encrypt tag names: %v", err)
+		return err
+	}
+	jr.key = key
+	return nil
+}
+
+// sealPlainTags seals the names of tags that were created before tag names
+// were encrypted, so every tag is found the same way once unlocked.
+func (jr *journalRepository) sealPlainTags(ctx context.Context, key *vault.Key) error {
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tags, err := readSealedTexts(ctx, tx.StmtContext(ctx, jr.plainTagsQuery))
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	to := sealedWith(key)
+	samples, err := resealTags(ctx, tags, tx.StmtContext(ctx, jr.resealTagQuery), plaintext, to, func() {})
+	if err != nil {
+		return err
+	}
+	if err := verifyTexts(ctx, "tag", tx.StmtContext(ctx, jr.sealedTagQuery), to, samples); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if _, err := jr.db.ExecContext(ctx, "VACUUM"); err != nil {
+		log.Printf("ERROR: failed to vacuum after encrypting tag names: %v", err)
+	}
+	return nil
+}
+
+// Lock forgets the key, so nothing can be read until the journal is unlocked
+// again.
+func (jr *journalRepository) Lock() {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	jr.key = nil
+}
+
+// Encrypt seals every journal, revision, draft and tag name with a key
+// derived from passphrase, in one transaction. The full-text index is emptied as it
+// would otherwise keep a plaintext copy, and the file is vacuumed so the
+// pages that held the plaintext are dropped. The journal stays unlocked.
+func (jr *journalRepository) Encrypt(ctx context.Context, passphrase string) error {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if jr.encrypted {
+		return domains.ErrEncrypted
+	}
+
+	params, err := vault.NewParams()
+	if err != nil {
+		return err
+	}
+	key, err := vault.DeriveKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+	verifier, err := key.Check()
+	if err != nil {
+		return err
+	}
+
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The index triggers stop firing once the encryption row exists.
+	if _, err := tx.StmtContext(ctx, jr.insertEncryptionQuery).ExecContext(ctx, params.Salt, params.Time, params.Memory, params.Threads, verifier); err != nil {
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, jr.clearIndexQuery).ExecContext(ctx); err != nil {
+		return err
+	}
+	if err := jr.reseal(ctx, tx, plaintext, sealedWith(key), nil); err != nil {
+		log.Printf("ERROR: failed to encrypt journals: %v", err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	jr.encrypted, jr.key = true, key
+
+	if _, err := jr.db.ExecContext(ctx, "VACUUM"); err != nil {
+		log.Printf("ERROR: failed to vacuum after encrypting: %v", err)
+	}
+	return nil
+}
+
+// Decrypt writes every journal, revision, draft and tag name back as
+// plaintext and indexes the journals for search again. The journal must be
+// unlocked.
+func (jr *journalRepository) Decrypt(ctx context.Context) error {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if !jr.encrypted {
+		return domains.ErrNotEncrypted
+	}
+	if jr.key == nil {
+		return domains.ErrLocked
+	}
+
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := jr.reseal(ctx, tx, sealedWith(jr.key), plaintext, nil); err != nil {
+		log.Printf("ERROR: failed to decrypt journals: %v", err)
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, jr.deleteEncryptionQuery).ExecContext(ctx); err != nil {
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, jr.rebuildIndexQuery).ExecContext(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	jr.encrypted, jr.key = false, nil
+	return nil
+}
+
+// ChangePassphrase re-encrypts every journal and revision with a key derived
+// from a new passphrase. It all happens in one transaction, so a journal is
+// never left partly under the old key and partly under the new one, even if
+// jou is killed halfway. The journal must be unlocked.
+func (jr *journalRepository) ChangePassphrase(ctx context.Context, passphrase string, progress func(done, total int)) error {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if !jr.encrypted {
+		return domains.ErrNotEncrypted
+	}
+	if jr.key == nil {
+		return domains.ErrLocked
+	}
+
+	params, err := vault.NewParams()
+	if err != nil {
+		return err
+	}
+	key, err := vault.DeriveKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+	verifier, err := key.Check()
+	if err != nil {
+		return err
+	}
+
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := jr.reseal(ctx, tx, sealedWith(jr.key), sealedWith(key), progress); err != nil {
+		log.Printf("ERROR: failed to re-encrypt journals: %v", err)
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, jr.updateEncryptionQuery).ExecContext(ctx, params.Salt, params.Time, params.Memory, params.Threads, verifier); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	jr.key = key
+
+	// Drop the pages that still hold text sealed with the old key
+	if _, err := jr.db.ExecContext(ctx, "VACUUM"); err != nil {
+		log.Printf("ERROR: failed to vacuum after re-encrypting: %v", err)
+	}
+	return nil
+}
+
+// codec turns text into what is stored and back, and a tag name into what
+// it is looked up by.
+type codec struct {
+	seal   func(string) (string, error)
+	open   func(string) (string, error)
+	lookup func(string) string
+}
+
+func plain(text string) (string, error) {
+	return text, nil
+}
+
+var plaintext = codec{seal: plain, open: plain, lookup: func(name string) string { return name }}
+
+func sealedWith(key *vault.Key) codec {
+	return codec{seal: key.Seal, open: key.Open, lookup: key.Hash}
+}
+
+// verifySamples is how many journals, and as many revisions and drafts,
+// reseal reads back before the transaction is committed.
+const verifySamples = 16
+
+// reseal rewrites the text of every journal, revision, draft and tag inside tx
+// from one codec to another, calling progress after every row. A sample of
+// the rows is then read back through the new codec to check nothing was
+// lost before the caller commits.
+func (jr *journalRepository) reseal(ctx context.Context, tx *sql.Tx, from, to codec, progress func(done, total int)) error {
+	// Rows are read up front as the statements share the transaction's
+	// connection.
+	type sealedJournal struct {
+		id                       int
+		title, content, location string
+	}
+	var journals []sealedJournal
+	rows, err := tx.StmtContext(ctx, jr.sealedJournalsQuery).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var j sealedJournal
+		if err := rows.Scan(&j.id, &j.title, &j.content, &j.location); err != nil {
+			rows.Close()
+			return err
+		}
+		journals = append(journals, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	revisions, err := readSealedTexts(ctx, tx.StmtContext(ctx, jr.sealedRevisionsQuery))
+	if err != nil {
+		return err
+	}
+	drafts, err := readSealedTexts(ctx, tx.StmtContext(ctx, jr.sealedDraftsQuery))
+	if err != nil {
+		return err
+	}
+	tags, err := readSealedTexts(ctx, tx.StmtContext(ctx, jr.sealedTagsQuery))
+	if err != nil {
+		return err
+	}
+
+	total := len(journals) + len(revisions) + len(drafts) + len(tags)
+	done := 0
+	step := func() {
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	// The opened text of every sampled row, to compare with what is read back
+	sampleEvery := max(1, len(journals)/verifySamples)
+	journalSamples := map[int][3]string{}
+	update := tx.StmtContext(ctx, jr.resealJournalQuery)
+	for i, j := range journals {
+		var opened [3]string
+		for k, text := range []*string{&j.title, &j.content, &j.location} {
+			if opened[k], err = from.open(*text); err != nil {
+				return fmt.Errorf("journal %d: %w", j.id, err)
+			}
+			if *text, err = to.seal(opened[k]); err != nil {
+				return fmt.Errorf("journal %d: %w", j.id, err)
+			}
+		}
+		if _, err := update.ExecContext(ctx, j.title, j.content, j.location, j.id); err != nil {
+			return err
+		}
+		if i%sampleEvery == 0 {
+			journalSamples[j.id] = opened
+		}
+		step()
+	}
+
+	revisionSamples, err := resealTexts(ctx, "revision", revisions, tx.StmtContext(ctx, jr.resealRevisionQuery), from, to, step)
+	if err != nil {
+		return err
+	}
+	draftSamples, err := resealTexts(ctx, "draft", drafts, tx.StmtContext(ctx, jr.resealDraftQuery), from, to, step)
+	if err != nil {
+		return err
+	}
+	tagSamples, err := resealTags(ctx, tags, tx.StmtContext(ctx, jr.resealTagQuery), from, to, step)
+	if err != nil {
+		return err
+	}
+
+	if err := jr.verifyResealed(ctx, tx, to, journalSamples); err != nil {
+		return err
+	}
+	if err := verifyTexts(ctx, "revision", tx.StmtContext(ctx, jr.sealedRevisionQuery), to, revisionSamples); err != nil {
+		return err
+	}
+	if err := verifyTexts(ctx, "draft", tx.StmtContext(ctx, jr.sealedDraftQuery), to, draftSamples); err != nil {
+		return err
+	}
+	return verifyTexts(ctx, "tag", tx.StmtContext(ctx, jr.sealedTagQuery), to, tagSamples)
+}
+
+// verifyResealed reads the sampled journals back inside tx and checks they
+// open to the text they held before.
+func (jr *journalRepository) verifyResealed(ctx context.Context, tx *sql.Tx, to codec, journals map[int][3]string) error {
+	read := tx.StmtContext(ctx, jr.sealedJournalQuery)
+	for id, want := range journals {
+		var got [3]string
+		if err := read.QueryRowContext(ctx, id).Scan(&got[0], &got[1], &got[2]); err != nil {
+			return fmt.Errorf("failed to read back journal %d: %w", id, err)
+		}
+		for k := range got {
+			opened, err := to.open(got[k])
+			if err != nil || opened != want[k] {
+				return fmt.Errorf("journal %d did not read back as written, nothing was changed", id)
+			}
+		}
+	}
+	return nil
+}
+
+// sealedText is a row of a table with a single sealed column, like
+// revisions, drafts and tags.
+type sealedText struct {
+	id      int
+	content string
+}
+
+func readSealedTexts(ctx context.Context, stmt *sql.Stmt) ([]sealedText, error) {
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var texts []sealedText
+	for rows.Next() {
+		var t sealedText
+		if err := rows.Scan(&t.id, &t.content); err != nil {
+			return nil, err
+		}
+		texts = append(texts, t)
+	}
+	return texts, rows.Err()
+}
+
+// resealTexts rewrites texts from one codec to another through update and
+// returns the opened text of a sample of them, by id.
+func resealTexts(ctx context.Context, kind string, texts []sealedText, update *sql.Stmt, from, to codec, step func()) (map[int]string, error) {
+	sampleEvery := max(1, len(texts)/verifySamples)
+	samples := map[int]string{}
+	for i, t := range texts {
+		opened, err := from.open(t.content)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", kind, t.id, err)
+		}
+		sealed, err := to.seal(opened)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", kind, t.id, err)
+		}
+		if _, err := update.ExecContext(ctx, sealed, t.id); err != nil {
+			return nil, err
+		}
+		if i%sampleEvery == 0 {
+			samples[t.id] = opened
+		}
+		step()
+	}
+	return samples, nil
+}
+
+// resealTags is resealTexts for tag names, which also get a new lookup.
+func resealTags(ctx context.Context, tags []sealedText, update *sql.Stmt, from, to codec, step func()) (map[int]string, error) {
+	samples := map[int]string{}
+	for _, t := range tags {
+		name, err := from.open(t.content)
+		if err != nil {
+			return nil, fmt.Errorf("tag %d: %w", t.id, err)
+		}
+		sealed, err := to.seal(name)
+		if err != nil {
+			return nil, fmt.Errorf("tag %d: %w", t.id, err)
+		}
+		if _, err := update.ExecContext(ctx, sealed, to.lookup(name), t.id); err != nil {
+			return nil, err
+		}
+		samples[t.id] = name
+		step()
+	}
+	return samples, nil
+}
+
+// verifyTexts is verifyResealed for the samples resealTexts returned.
+func verifyTexts(ctx context.Context, kind string, read *sql.Stmt, to codec, samples map[int]string) error {
+	for id, want := range samples {
+		var got string
+		if err := read.QueryRowContext(ctx, id).Scan(&got); err != nil {
+			return fmt.Errorf("failed to read back %s %d: %w", kind, id, err)
+		}
+		if opened, err := to.open(got); err != nil || opened != want {
+			return fmt.Errorf("%s %d did not read back as written, nothing was changed", kind, id)
+		}
+	}
+	return nil
+}
+
+// seal encrypts text when the journal is encrypted and returns it unchanged
+// otherwise.
+func (jr *journalRepository) seal(text string) (string, error) {
+	jr.mu.RLock()
+	defer jr.mu.RUnlock()
+	if !jr.encrypted {
+		return text, nil
+	}
+	if jr.key == nil {
+		return "", domains.ErrLocked
+	}
+	return jr.key.Seal(text)
+}
+
+// lookup returns what a tag is found by: its name, or a keyed hash of it
+// while the journal is encrypted and the name is sealed.
+func (jr *journalRepository) lookup(name string) (string, error) {
+	jr.mu.RLock()
+	defer jr.mu.RUnlock()
+	if !jr.encrypted {
+		return name, nil
+	}
+	if jr.key == nil {
+		return "", domains.ErrLocked
+	}
+	return jr.key.Hash(name), nil
+}
+
+// open is the reverse of seal.
+func (jr *journalRepository) open(text string) (string, error) {
+	jr.mu.RLock()
+	defer jr.mu.RUnlock()
+	if !jr.encrypted {
+		return text, nil
+	}
+	if jr.key == nil {
+		return "", domains.ErrLocked
+	}
+	opened, err := jr.key.Open(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt journal text: %w", err)
+	}
+	return opened, nil
+}
+
+// sealJournal returns a copy of journal with its text sealed.
+func (jr *journalRepository) sealJournal(journal domains.Journal) (domains.Journal, error) {
+	var err error
+	for _, text := range []*string{&journal.Title, &journal.Content, &journal.Location} {
+		if *text, err = jr.seal(*text); err != nil {
+			return journal, err
+		}
+	}
+	return journal, nil
+}
+
+// openJournal opens the sealed text of a journal in place.
+func (jr *journalRepository) openJournal(journal *domains.Journal) error {
+	var err error
+	for _, text := range []*string{&journal.Title, &journal.Content, &journal.Location} {
+		if *text, err = jr.open(*text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cheersmas/jou/database"
+	"github.com/cheersmas/jou/domains"
+)
+
+// newRepository returns a repository over a fresh database.
+func newRepository(t *testing.T) *journalRepository {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), database.DB_FILE_NAME)
+	db, err := database.NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(ctx, db, path); err != nil {
+		t.Fatal(err)
+	}
+	jr, err := NewJournalRepository(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jr
+}
+
+func TestEncryptSealsEverything(t *testing.T) {
+	ctx := context.Background()
+	jr := newRepository(t)
+	if _, err := jr.Create(ctx, domains.Journal{Title: "Plans", Content: "a trip to #lisbon", Tags: []string{"travel"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jr.Encrypt(ctx, "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM journals WHERE title = 'Plans' OR content LIKE '%lisbon%'",
+		"SELECT COUNT(*) FROM tags WHERE name IN ('lisbon', 'travel') OR lookup IN ('lisbon', 'travel')",
+	} {
+		var n int
+		if err := jr.db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%s = %d, want nothing in plaintext", query, n)
+		}
+	}
+
+	journals, err := jr.ListByTag(ctx, "travel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journals) != 1 || !slices.Equal(journals[0].Tags, []string{"lisbon", "travel"}) {
+		t.Errorf("ListByTag(travel) = %v, want the journal with both tags", journals)
+	}
+
+	results, err := jr.Search(ctx, "trip NOT rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Search found %d journals, want 1", len(results))
+	}
+
+	if err := jr.Decrypt(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := jr.ListTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags[0].Name != "lisbon" || tags[1].Name != "travel" {
+		t.Errorf("tags after Decrypt = %v, want lisbon and travel", tags)
+	}
+}
+
+func TestChangePassphraseRollsBackWhenVerificationFails(t *testing.T) {
+	ctx := context.Background()
+	jr := newRepository(t)
+	id, err := jr.Create(ctx, domains.Journal{Content: "private #secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jr.Encrypt(ctx, "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt whatever is written, so reading it back fails.
+	if _, err := jr.db.ExecContext(ctx, `
+		CREATE TRIGGER corrupt AFTER UPDATE OF content ON journals
+		BEGIN UPDATE journals SET content = 'corrupted' WHERE id = new.id; END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := jr.ChangePassphrase(ctx, "new", nil); err == nil {
+		t.Fatal("ChangePassphrase succeeded with corrupted writes")
+	}
+	if _, err := jr.db.ExecContext(ctx, "DROP TRIGGER corrupt"); err != nil {
+		t.Fatal(err)
+	}
+
+	jr.Lock()
+	if err := jr.Unlock(ctx, "new"); !errors.Is(err, domains.ErrWrongPassphrase) {
+		t.Errorf("Unlock with the new passphrase = %v, want ErrWrongPassphrase", err)
+	}
+	if err := jr.Unlock(ctx, "old"); err != nil {
+		t.Fatalf("Unlock with the old passphrase: %v", err)
+	}
+	journal, err := jr.Read(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journal.Content != "private #secret" || !slices.Equal(journal.Tags, []string{"secret"}) {
+		t.Errorf("journal = %q %q, want it unchanged", journal.Content, journal.Tags)
+	}
+}
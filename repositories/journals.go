@@ -5,112 +5,480 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cheersmas/jou/domains"
+	"github.com/cheersmas/jou/vault"
 )
 
-const createJournalTable string = `
-	CREATE TABLE IF NOT EXISTS journals (
-	id INTEGER NOT NULL PRIMARY KEY,
-	content TEXT NOT NULL,
-	createdAt DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-`
+// journalColumns is the column list scanJournal expects, in order. Columns
+// are qualified so queries can join journals with other tables, and tags are
+// folded into a single space separated column. Sealed tag names are base64,
+// so they never contain a space either.
+const journalColumns = `journals.id, journals.title, journals.content, journals.starred, journals.location,
+	journals.entryDate, journals.createdAt, journals.updatedAt, journals.deletedAt,
+	(SELECT group_concat(tags.name, ' ') FROM journal_tags JOIN tags ON tags.id = journal_tags.tagId
+		WHERE journal_tags.journalId = journals.id)`
 
 type journalRepository struct {
 	db *sql.DB
 
+	// encrypted is set while journal text is sealed, and key once the
+	// journal has been unlocked. The key is never written anywhere.
+	mu        sync.RWMutex
+	encrypted bool
+	key       *vault.Key
+
 	// queries
 	readJournalQuery    *sql.Stmt
+	contentQuery        *sql.Stmt
 	existsJournalQuery  *sql.Stmt
 	insertJournalQuery  *sql.Stmt
 	deleteJournalQuery  *sql.Stmt
 	updateJournalQuery  *sql.Stmt
+	entryDateQuery      *sql.Stmt
+	titleQuery          *sql.Stmt
+	retagJournalQuery   *sql.Stmt
 	listAllJournalQuery *sql.Stmt
+	eachJournalQuery    *sql.Stmt
+	searchJournalQuery  *sql.Stmt
+
+	// trash
+	listDeletedJournalQuery *sql.Stmt
+	restoreJournalQuery     *sql.Stmt
+	purgeJournalQuery       *sql.Stmt
+	purgeDeletedBeforeQuery *sql.Stmt
+
+	// revisions
+	snapshotRevisionQuery *sql.Stmt
+	readRevisionQuery     *sql.Stmt
+	listRevisionsQuery    *sql.Stmt
+
+	// drafts
+	insertDraftQuery *sql.Stmt
+	updateDraftQuery *sql.Stmt
+	touchDraftQuery  *sql.Stmt
+	listDraftsQuery  *sql.Stmt
+	deleteDraftQuery *sql.Stmt
+
+	// encryption
+	encryptionQuery       *sql.Stmt
+	insertEncryptionQuery *sql.Stmt
+	updateEncryptionQuery *sql.Stmt
+	deleteEncryptionQuery *sql.Stmt
+	sealedJournalsQuery   *sql.Stmt
+	sealedJournalQuery    *sql.Stmt
+	resealJournalQuery    *sql.Stmt
+	sealedRevisionsQuery  *sql.Stmt
+	sealedRevisionQuery   *sql.Stmt
+	resealRevisionQuery   *sql.Stmt
+	sealedDraftsQuery     *sql.Stmt
+	sealedDraftQuery      *sql.Stmt
+	resealDraftQuery      *sql.Stmt
+	sealedTagsQuery       *sql.Stmt
+	sealedTagQuery        *sql.Stmt
+	resealTagQuery        *sql.Stmt
+	plainTagsQuery        *sql.Stmt
+	clearIndexQuery       *sql.Stmt
+	rebuildIndexQuery     *sql.Stmt
+
+	// tags
+	insertTagQuery       *sql.Stmt
+	tagJournalQuery      *sql.Stmt
+	clearInlineTagsQuery *sql.Stmt
+	clearTagsQuery       *sql.Stmt
+	pruneTagsQuery       *sql.Stmt
+	listByTagQuery       *sql.Stmt
+	listTagsQuery        *sql.Stmt
+	tagIdQuery           *sql.Stmt
+	taggedContentQuery   *sql.Stmt
+	renameTagQuery       *sql.Stmt
+	moveTagQuery         *sql.Stmt
+	deleteTagQuery       *sql.Stmt
+}
+
+// storedTime is how a time is written to the database. The driver stores
+// times as text with their zone, so they are all kept in UTC to sort and
+// compare by instant. They are read back in local time.
+func storedTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJournal reads the journalColumns of a row, followed by any extra
+// columns selected after them, and opens its sealed text.
+func (jr *journalRepository) scanJournal(row rowScanner, extra ...any) (domains.Journal, error) {
+	var journal domains.Journal
+	var updatedAt, deletedAt sql.NullTime
+	var tags sql.NullString
+
+	dest := append([]any{&journal.Id, &journal.Title, &journal.Content, &journal.Starred, &journal.Location, &journal.EntryDate, &journal.CreatedAt, &updatedAt, &deletedAt, &tags}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return journal, err
+	}
+
+	if tags.Valid {
+		journal.Tags = strings.Fields(tags.String)
+		for i, tag := range journal.Tags {
+			var err error
+			if journal.Tags[i], err = jr.open(tag); err != nil {
+				return journal, err
+			}
+		}
+		slices.Sort(journal.Tags)
+	}
+	journal.EntryDate = journal.EntryDate.Local()
+	journal.CreatedAt = journal.CreatedAt.Local()
+	if updatedAt.Valid {
+		updated := updatedAt.Time.Local()
+		journal.UpdatedAt = &updated
+	}
+	if deletedAt.Valid {
+		deleted := deletedAt.Time.Local()
+		journal.DeletedAt = &deleted
+	}
+	return journal, jr.openJournal(&journal)
+}
+
+func (jr *journalRepository) queryJournals(ctx context.Context, stmt *sql.Stmt, args ...any) ([]domains.Journal, error) {
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		log.Printf("ERROR: failed to query journals: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var journals []domains.Journal
+	for rows.Next() {
+		journal, err := jr.scanJournal(rows)
+		if err != nil {
+			log.Printf("ERROR: failed to scan journal row: %v", err)
+			return nil, err
+		}
+		journals = append(journals, journal)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("ERROR: error after scanning rows: %v", err)
+		return nil, err
+	}
+
+	return journals, nil
 }
 
+// Create inserts a journal. CreatedAt is always now, EntryDate defaults to
+// it but is kept when set so entries can be backdated or imported with their
+// original date. Tags are stored as explicit tags next to the ones found in
+// the content.
 func (jr *journalRepository) Create(ctx context.Context, content domains.Journal) (int, error) {
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	id, err := jr.create(ctx, tx, content)
+	if err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+// create is Create inside tx.
+func (jr *journalRepository) create(ctx context.Context, tx *sql.Tx, content domains.Journal) (int, error) {
 	// Use Go's time.Now() to ensure consistent timezone handling
-	now := time.Now()
-	res, err := jr.insertJournalQuery.ExecContext(ctx, content.Content, now)
+	createdAt := time.Now()
+	entryDate := content.EntryDate
+	if entryDate.IsZero() {
+		entryDate = createdAt
+	}
+
+	sealed, err := jr.sealJournal(content)
+	if err != nil {
+		return -1, err
+	}
+
+	res, err := tx.StmtContext(ctx, jr.insertJournalQuery).ExecContext(ctx, sealed.Title, sealed.Content, sealed.Starred, sealed.Location, storedTime(entryDate), storedTime(createdAt))
 	if err != nil {
 		log.Printf("ERROR: failed to create a journal entry: %v", err)
 		return -1, err
 	}
-	var id int64
-	if id, err = res.LastInsertId(); err != nil {
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	if err := jr.tagJournal(ctx, tx, int(id), content.Tags, true); err != nil {
+		return -1, err
+	}
+	if err := jr.tagJournal(ctx, tx, int(id), domains.ExtractTags(content.Content), false); err != nil {
 		return -1, err
 	}
 	return int(id), nil
 }
 
 func (jr *journalRepository) Read(ctx context.Context, journalId int) (domains.Journal, error) {
-	var journal domains.Journal
-	if err := jr.readJournalQuery.QueryRowContext(ctx, journalId).Scan(&journal.Id, &journal.Content, &journal.CreatedAt); err == sql.ErrNoRows {
-		return journal, err
+	journal, err := jr.scanJournal(jr.readJournalQuery.QueryRowContext(ctx, journalId))
+	if err == sql.ErrNoRows {
+		return journal, fmt.Errorf("%w: id %d", domains.ErrJournalNotFound, journalId)
 	}
-	return journal, nil
+	return journal, err
 }
 
 func (jr *journalRepository) ListAll(ctx context.Context) ([]domains.Journal, error) {
-	rows, err := jr.listAllJournalQuery.QueryContext(ctx)
+	return jr.queryJournals(ctx, jr.listAllJournalQuery)
+}
+
+// Each calls fn for every journal not in the trash, oldest first, without
+// loading them all into memory. It stops at the first error fn returns.
+func (jr *journalRepository) Each(ctx context.Context, fn func(domains.Journal) error) error {
+	rows, err := jr.eachJournalQuery.QueryContext(ctx)
 	if err != nil {
 		log.Printf("ERROR: failed to query journals: %v", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var journals []domains.Journal
 	for rows.Next() {
-		var journal domains.Journal
-		if err := rows.Scan(&journal.Id, &journal.Content, &journal.CreatedAt); err != nil {
+		journal, err := jr.scanJournal(rows)
+		if err != nil {
 			log.Printf("ERROR: failed to scan journal row: %v", err)
+			return err
+		}
+		if err := fn(journal); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (jr *journalRepository) Search(ctx context.Context, query string) ([]domains.SearchResult, error) {
+	if jr.Encrypted() {
+		return jr.searchSealed(ctx, query)
+	}
+
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := jr.searchJournalQuery.QueryContext(ctx, domains.HighlightStart, domains.HighlightEnd, match)
+	if err != nil {
+		log.Printf("ERROR: failed to search journals: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domains.SearchResult
+	for rows.Next() {
+		var result domains.SearchResult
+		if result.Journal, err = jr.scanJournal(rows, &result.Snippet, &result.Rank); err != nil {
+			log.Printf("ERROR: failed to scan search row: %v", err)
 			return nil, err
 		}
-		journals = append(journals, journal)
+		results = append(results, result)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("ERROR: error after scanning rows: %v", err)
+		log.Printf("ERROR: error after scanning search rows: %v", err)
 		return nil, err
 	}
 
-	return journals, nil
+	return results, nil
 }
 
+// Update replaces a journal's content, keeping the previous content as a
+// revision when it changed, and re-reads its inline tags.
 func (jr *journalRepository) Update(ctx context.Context, id int, content string) (int, error) {
-	res, err := jr.updateJournalQuery.ExecContext(ctx, content, id)
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	if err := jr.update(ctx, tx, id, content); err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+// update is Update inside tx.
+func (jr *journalRepository) update(ctx context.Context, tx *sql.Tx, id int, content string) error {
+	sealed, err := jr.seal(content)
+	if err != nil {
+		return err
+	}
+
+	if err := jr.snapshot(ctx, tx, id, content); err != nil {
+		log.Printf("ERROR: failed to snapshot journal %d: %v", id, err)
+		return err
+	}
+
+	res, err := tx.StmtContext(ctx, jr.updateJournalQuery).ExecContext(ctx, sealed, storedTime(time.Now()), id)
 	if err != nil {
-		return -1, nil
+		return err
 	}
 	rowsEffected, err := res.RowsAffected()
 	if err != nil {
-		return -1, err
+		return err
 	}
 	if rowsEffected == 0 {
-		return -1, fmt.Errorf("no journal found with id %d", id)
+		return fmt.Errorf("%w: id %d", domains.ErrJournalNotFound, id)
 	}
 
-	return int(id), nil
+	return jr.retagInline(ctx, tx, id, content)
+}
+
+// snapshot keeps a journal's current content as a revision unless it is the
+// same as content. Sealed text differs every time it is written, so the
+// contents are compared once opened.
+func (jr *journalRepository) snapshot(ctx context.Context, tx *sql.Tx, id int, content string) error {
+	var current string
+	err := tx.StmtContext(ctx, jr.contentQuery).QueryRowContext(ctx, id).Scan(&current)
+	if err == sql.ErrNoRows {
+		// the caller reports the missing journal
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current, err = jr.open(current); err != nil {
+		return err
+	}
+	if current == content {
+		return nil
+	}
+	_, err = tx.StmtContext(ctx, jr.snapshotRevisionQuery).ExecContext(ctx, id)
+	return err
+}
+
+// SetEntryDate moves a journal to another date. It is not a content edit, so
+// no revision is kept and updatedAt is left alone.
+func (jr *journalRepository) SetEntryDate(ctx context.Context, id int, date time.Time) (int, error) {
+	return jr.execById(ctx, jr.entryDateQuery, id, storedTime(date), id)
+}
+
+// SetTitle gives a journal an explicit title, or clears it when title is
+// empty. Like SetEntryDate it is not treated as a content edit.
+func (jr *journalRepository) SetTitle(ctx context.Context, id int, title string) (int, error) {
+	sealed, err := jr.seal(title)
+	if err != nil {
+		return -1, err
+	}
+	return jr.execById(ctx, jr.titleQuery, id, sealed, id)
 }
 
+func (jr *journalRepository) ReadRevision(ctx context.Context, revisionId int) (domains.Revision, error) {
+	var revision domains.Revision
+	err := jr.readRevisionQuery.QueryRowContext(ctx, revisionId).Scan(&revision.Id, &revision.JournalId, &revision.Content, &revision.CreatedAt)
+	if err == sql.ErrNoRows {
+		return revision, fmt.Errorf("%w: id %d", domains.ErrRevisionNotFound, revisionId)
+	}
+	if err != nil {
+		return revision, err
+	}
+	revision.CreatedAt = revision.CreatedAt.Local()
+	revision.Content, err = jr.open(revision.Content)
+	return revision, err
+}
+
+// ListRevisions returns the earlier versions of a journal, newest first.
+func (jr *journalRepository) ListRevisions(ctx context.Context, journalId int) ([]domains.Revision, error) {
+	rows, err := jr.listRevisionsQuery.QueryContext(ctx, journalId)
+	if err != nil {
+		log.Printf("ERROR: failed to query revisions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []domains.Revision
+	for rows.Next() {
+		var revision domains.Revision
+		if err := rows.Scan(&revision.Id, &revision.JournalId, &revision.Content, &revision.CreatedAt); err != nil {
+			log.Printf("ERROR: failed to scan revision row: %v", err)
+			return nil, err
+		}
+		revision.CreatedAt = revision.CreatedAt.Local()
+		if revision.Content, err = jr.open(revision.Content); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("ERROR: error after scanning revision rows: %v", err)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Delete moves a journal to the trash. It stays there, hidden from ListAll
+// and Search, until it is restored or purged.
 func (jr *journalRepository) Delete(ctx context.Context, id int) (int, error) {
-	_, err := jr.deleteJournalQuery.ExecContext(ctx, id)
+	return jr.execById(ctx, jr.deleteJournalQuery, id, storedTime(time.Now()), id)
+}
+
+func (jr *journalRepository) ListDeleted(ctx context.Context) ([]domains.Journal, error) {
+	return jr.queryJournals(ctx, jr.listDeletedJournalQuery)
+}
+
+func (jr *journalRepository) Restore(ctx context.Context, id int) (int, error) {
+	return jr.execById(ctx, jr.restoreJournalQuery, id, id)
+}
+
+// Purge permanently removes a journal from the trash along with its
+// revisions.
+func (jr *journalRepository) Purge(ctx context.Context, id int) (int, error) {
+	return jr.execById(ctx, jr.purgeJournalQuery, id, id)
+}
+
+// PurgeDeletedBefore empties the trash of journals deleted before the given
+// time and returns how many were removed.
+func (jr *journalRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := jr.purgeDeletedBeforeQuery.ExecContext(ctx, storedTime(before))
+	if err != nil {
+		log.Printf("ERROR: failed to purge the trash: %v", err)
+		return 0, err
+	}
+	purged, err := res.RowsAffected()
+	return int(purged), err
+}
+
+// execById runs a statement that targets a single journal and fails if no
+// journal matched.
+func (jr *journalRepository) execById(ctx context.Context, stmt *sql.Stmt, id int, args ...any) (int, error) {
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		return -1, err
 	}
-	return id, err
+	rowsEffected, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+	if rowsEffected == 0 {
+		return -1, fmt.Errorf("%w: id %d", domains.ErrJournalNotFound, id)
+	}
+	return id, nil
 }
 
 func NewJournalRepository(ctx context.Context, db *sql.DB) (*journalRepository, error) {
-	// Create tables if they don't exist
-	if _, err := db.ExecContext(ctx, createJournalTable); err != nil {
-		log.Printf("%q %s\n", err, createJournalTable)
+	readJournalQuery, err := db.PrepareContext(ctx, "SELECT "+journalColumns+" FROM journals WHERE id = ?")
+	if err != nil {
 		return nil, err
 	}
-
-	readJournalQuery, err := db.PrepareContext(ctx, "SELECT id, content, createdAt FROM journals WHERE id = ?")
+	contentQuery, err := db.PrepareContext(ctx, "SELECT content FROM journals WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
@@ -118,31 +486,334 @@ func NewJournalRepository(ctx context.Context, db *sql.DB) (*journalRepository,
 	if err != nil {
 		return nil, err
 	}
-	// Updated to include createdAt parameter
-	insertJournalQuery, err := db.PrepareContext(ctx, "INSERT INTO journals(content, createdAt) VALUES(?, ?)")
+	insertJournalQuery, err := db.PrepareContext(ctx, "INSERT INTO journals(title, content, starred, location, entryDate, createdAt) VALUES(?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return nil, err
+	}
+	deleteJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET deletedAt = ? WHERE id = ? AND deletedAt IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	// Journals in the trash can't be edited until they are restored.
+	updateJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET content = ?, updatedAt = ? WHERE id = ? AND deletedAt IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	entryDateQuery, err := db.PrepareContext(ctx, "UPDATE journals SET entryDate = ? WHERE id = ? AND deletedAt IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	titleQuery, err := db.PrepareContext(ctx, "UPDATE journals SET title = ? WHERE id = ? AND deletedAt IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	// Renaming a tag rewrites journals in the trash too, so they come back
+	// with the new name.
+	retagJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET content = ?, updatedAt = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	listAllJournalQuery, err := db.PrepareContext(ctx, "SELECT "+journalColumns+" FROM journals WHERE deletedAt IS NULL ORDER BY entryDate DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	eachJournalQuery, err := db.PrepareContext(ctx, "SELECT "+journalColumns+" FROM journals WHERE deletedAt IS NULL ORDER BY entryDate, id")
+	if err != nil {
+		return nil, err
+	}
+	// bm25 scores are negative, lower is a better match. The snippet comes
+	// from whichever of content and title matched best.
+	searchJournalQuery, err := db.PrepareContext(ctx, `
+		SELECT `+journalColumns+`,
+			snippet(journals_fts, -1, ?, ?, '…', 16),
+			bm25(journals_fts)
+		FROM journals_fts
+		JOIN journals ON journals.id = journals_fts.rowid
+		WHERE journals_fts MATCH ? AND journals.deletedAt IS NULL
+		ORDER BY bm25(journals_fts)`)
+	if err != nil {
+		return nil, err
+	}
+	listDeletedJournalQuery, err := db.PrepareContext(ctx, "SELECT "+journalColumns+" FROM journals WHERE deletedAt IS NOT NULL ORDER BY deletedAt DESC")
+	if err != nil {
+		return nil, err
+	}
+	restoreJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET deletedAt = NULL WHERE id = ? AND deletedAt IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	// Only journals already in the trash can be purged; revisions go with
+	// them through ON DELETE CASCADE.
+	purgeJournalQuery, err := db.PrepareContext(ctx, "DELETE FROM journals WHERE id = ? AND deletedAt IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	purgeDeletedBeforeQuery, err := db.PrepareContext(ctx, "DELETE FROM journals WHERE deletedAt IS NOT NULL AND deletedAt < ?")
+	if err != nil {
+		return nil, err
+	}
+
+	// The revision is dated when its content was written, which is the last
+	// edit or, for the first version, the creation time.
+	snapshotRevisionQuery, err := db.PrepareContext(ctx, `
+		INSERT INTO journal_revisions(journalId, content, createdAt)
+		SELECT id, content, COALESCE(updatedAt, createdAt) FROM journals
+		WHERE id = ?`)
+	if err != nil {
+		return nil, err
+	}
+	readRevisionQuery, err := db.PrepareContext(ctx, "SELECT id, journalId, content, createdAt FROM journal_revisions WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	listRevisionsQuery, err := db.PrepareContext(ctx, "SELECT id, journalId, content, createdAt FROM journal_revisions WHERE journalId = ? ORDER BY createdAt DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	insertDraftQuery, err := db.PrepareContext(ctx, "INSERT INTO drafts(journalId, content, entryDate, updatedAt) VALUES(?, ?, ?, ?)")
+	if err != nil {
+		return nil, err
+	}
+	updateDraftQuery, err := db.PrepareContext(ctx, "UPDATE drafts SET content = ?, entryDate = ?, updatedAt = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	touchDraftQuery, err := db.PrepareContext(ctx, "UPDATE drafts SET updatedAt = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	listDraftsQuery, err := db.PrepareContext(ctx, "SELECT id, journalId, content, entryDate, updatedAt FROM drafts ORDER BY updatedAt DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	deleteDraftQuery, err := db.PrepareContext(ctx, "DELETE FROM drafts WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+
+	insertTagQuery, err := db.PrepareContext(ctx, "INSERT INTO tags(name, lookup) VALUES(?, ?) ON CONFLICT(lookup) DO NOTHING")
+	if err != nil {
+		return nil, err
+	}
+	// A tag that is both written inline and set explicitly stays explicit.
+	tagJournalQuery, err := db.PrepareContext(ctx, `
+		INSERT INTO journal_tags(journalId, tagId, explicit)
+		SELECT ?, id, ? FROM tags WHERE lookup = ?
+		ON CONFLICT(journalId, tagId) DO UPDATE SET explicit = MAX(explicit, excluded.explicit)`)
+	if err != nil {
+		return nil, err
+	}
+	clearInlineTagsQuery, err := db.PrepareContext(ctx, "DELETE FROM journal_tags WHERE journalId = ? AND explicit = 0")
+	if err != nil {
+		return nil, err
+	}
+	clearTagsQuery, err := db.PrepareContext(ctx, "DELETE FROM journal_tags WHERE journalId = ?")
+	if err != nil {
+		return nil, err
+	}
+	pruneTagsQuery, err := db.PrepareContext(ctx, "DELETE FROM tags WHERE NOT EXISTS (SELECT 1 FROM journal_tags WHERE tagId = tags.id)")
+	if err != nil {
+		return nil, err
+	}
+	listByTagQuery, err := db.PrepareContext(ctx, `
+		SELECT `+journalColumns+` FROM journals
+		WHERE deletedAt IS NULL AND id IN (
+			SELECT journal_tags.journalId FROM journal_tags
+			JOIN tags ON tags.id = journal_tags.tagId
+			WHERE tags.lookup = ?)
+		ORDER BY entryDate DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	// Sealed names do not sort, so rows are grouped by tag and sorted by
+	// name once opened.
+	listTagsQuery, err := db.PrepareContext(ctx, `
+		SELECT tags.id, tags.name, journals.entryDate FROM tags
+		JOIN journal_tags ON journal_tags.tagId = tags.id
+		JOIN journals ON journals.id = journal_tags.journalId
+		WHERE journals.deletedAt IS NULL
+		ORDER BY tags.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIdQuery, err := db.PrepareContext(ctx, "SELECT id FROM tags WHERE lookup = ?")
+	if err != nil {
+		return nil, err
+	}
+	// Trashed journals are included so a restored entry carries the new name.
+	taggedContentQuery, err := db.PrepareContext(ctx, `
+		SELECT journals.id, journals.content FROM journals
+		JOIN journal_tags ON journal_tags.journalId = journals.id
+		WHERE journal_tags.tagId = ?`)
+	if err != nil {
+		return nil, err
+	}
+	renameTagQuery, err := db.PrepareContext(ctx, "UPDATE tags SET name = ?, lookup = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	moveTagQuery, err := db.PrepareContext(ctx, `
+		INSERT INTO journal_tags(journalId, tagId, explicit)
+		SELECT journalId, ?, explicit FROM journal_tags WHERE tagId = ?
+		ON CONFLICT(journalId, tagId) DO UPDATE SET explicit = MAX(explicit, excluded.explicit)`)
+	if err != nil {
+		return nil, err
+	}
+	// journal_tags rows go with the tag through ON DELETE CASCADE.
+	deleteTagQuery, err := db.PrepareContext(ctx, "DELETE FROM tags WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionQuery, err := db.PrepareContext(ctx, "SELECT salt, time, memory, threads, verifier FROM encryption")
+	if err != nil {
+		return nil, err
+	}
+	insertEncryptionQuery, err := db.PrepareContext(ctx, "INSERT INTO encryption(id, salt, time, memory, threads, verifier) VALUES(1, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return nil, err
+	}
+	updateEncryptionQuery, err := db.PrepareContext(ctx, "UPDATE encryption SET salt = ?, time = ?, memory = ?, threads = ?, verifier = ?")
+	if err != nil {
+		return nil, err
+	}
+	deleteEncryptionQuery, err := db.PrepareContext(ctx, "DELETE FROM encryption")
+	if err != nil {
+		return nil, err
+	}
+	// Trashed journals and revisions are sealed too. Rewriting them is not
+	// an edit, so updatedAt is left alone.
+	sealedJournalsQuery, err := db.PrepareContext(ctx, "SELECT id, title, content, location FROM journals")
+	if err != nil {
+		return nil, err
+	}
+	sealedJournalQuery, err := db.PrepareContext(ctx, "SELECT title, content, location FROM journals WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	resealJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET title = ?, content = ?, location = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	sealedRevisionsQuery, err := db.PrepareContext(ctx, "SELECT id, content FROM journal_revisions")
+	if err != nil {
+		return nil, err
+	}
+	sealedRevisionQuery, err := db.PrepareContext(ctx, "SELECT content FROM journal_revisions WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	resealRevisionQuery, err := db.PrepareContext(ctx, "UPDATE journal_revisions SET content = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	sealedDraftsQuery, err := db.PrepareContext(ctx, "SELECT id, content FROM drafts")
+	if err != nil {
+		return nil, err
+	}
+	sealedDraftQuery, err := db.PrepareContext(ctx, "SELECT content FROM drafts WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
-	deleteJournalQuery, err := db.PrepareContext(ctx, "DELETE FROM journals WHERE id = ?")
+	resealDraftQuery, err := db.PrepareContext(ctx, "UPDATE drafts SET content = ? WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
-	updateJournalQuery, err := db.PrepareContext(ctx, "UPDATE journals SET content = ? WHERE id = ?")
+	sealedTagsQuery, err := db.PrepareContext(ctx, "SELECT id, name FROM tags")
 	if err != nil {
 		return nil, err
 	}
-	listAllJournalQuery, err := db.PrepareContext(ctx, "SELECT id, content, createdAt FROM journals ORDER BY createdAt DESC")
+	sealedTagQuery, err := db.PrepareContext(ctx, "SELECT name FROM tags WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
+	resealTagQuery, err := db.PrepareContext(ctx, "UPDATE tags SET name = ?, lookup = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	// Tags created before their names were sealed are still found by name.
+	plainTagsQuery, err := db.PrepareContext(ctx, "SELECT id, name FROM tags WHERE lookup = name")
+	if err != nil {
+		return nil, err
+	}
+	clearIndexQuery, err := db.PrepareContext(ctx, "INSERT INTO journals_fts(journals_fts) VALUES ('delete-all')")
+	if err != nil {
+		return nil, err
+	}
+	rebuildIndexQuery, err := db.PrepareContext(ctx, "INSERT INTO journals_fts(journals_fts) VALUES ('rebuild')")
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM encryption)").Scan(&encrypted); err != nil {
+		return nil, err
+	}
 
 	return &journalRepository{
 		db:                  db,
+		encrypted:           encrypted,
 		readJournalQuery:    readJournalQuery,
+		contentQuery:        contentQuery,
 		existsJournalQuery:  existsJournalQuery,
 		insertJournalQuery:  insertJournalQuery,
 		deleteJournalQuery:  deleteJournalQuery,
 		updateJournalQuery:  updateJournalQuery,
+		entryDateQuery:      entryDateQuery,
+		titleQuery:          titleQuery,
+		retagJournalQuery:   retagJournalQuery,
 		listAllJournalQuery: listAllJournalQuery,
+		eachJournalQuery:    eachJournalQuery,
+		searchJournalQuery:  searchJournalQuery,
+
+		listDeletedJournalQuery: listDeletedJournalQuery,
+		restoreJournalQuery:     restoreJournalQuery,
+		purgeJournalQuery:       purgeJournalQuery,
+		purgeDeletedBeforeQuery: purgeDeletedBeforeQuery,
+
+		snapshotRevisionQuery: snapshotRevisionQuery,
+		readRevisionQuery:     readRevisionQuery,
+		listRevisionsQuery:    listRevisionsQuery,
+
+		insertDraftQuery: insertDraftQuery,
+		updateDraftQuery: updateDraftQuery,
+		touchDraftQuery:  touchDraftQuery,
+		listDraftsQuery:  listDraftsQuery,
+		deleteDraftQuery: deleteDraftQuery,
+
+		encryptionQuery:       encryptionQuery,
+		insertEncryptionQuery: insertEncryptionQuery,
+		updateEncryptionQuery: updateEncryptionQuery,
+		deleteEncryptionQuery: deleteEncryptionQuery,
+		sealedJournalsQuery:   sealedJournalsQuery,
+		sealedJournalQuery:    sealedJournalQuery,
+		resealJournalQuery:    resealJournalQuery,
+		sealedRevisionsQuery:  sealedRevisionsQuery,
+		sealedRevisionQuery:   sealedRevisionQuery,
+		resealRevisionQuery:   resealRevisionQuery,
+		sealedDraftsQuery:     sealedDraftsQuery,
+		sealedDraftQuery:      sealedDraftQuery,
+		resealDraftQuery:      resealDraftQuery,
+		sealedTagsQuery:       sealedTagsQuery,
+		sealedTagQuery:        sealedTagQuery,
+		resealTagQuery:        resealTagQuery,
+		plainTagsQuery:        plainTagsQuery,
+		clearIndexQuery:       clearIndexQuery,
+		rebuildIndexQuery:     rebuildIndexQuery,
+
+		insertTagQuery:       insertTagQuery,
+		tagJournalQuery:      tagJournalQuery,
+		clearInlineTagsQuery: clearInlineTagsQuery,
+		clearTagsQuery:       clearTagsQuery,
+		pruneTagsQuery:       pruneTagsQuery,
+		listByTagQuery:       listByTagQuery,
+		listTagsQuery:        listTagsQuery,
+		tagIdQuery:           tagIdQuery,
+		taggedContentQuery:   taggedContentQuery,
+		renameTagQuery:       renameTagQuery,
+		moveTagQuery:         moveTagQuery,
+		deleteTagQuery:       deleteTagQuery,
 	}, nil
 }
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+func TestTrashedJournalsCannotBeEdited(t *testing.T) {
+	ctx := context.Background()
+	jr := newRepository(t)
+	id, err := jr.Create(ctx, domains.Journal{Content: "in the #trash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jr.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	edits := map[string]func() (int, error){
+		"Update":       func() (int, error) { return jr.Update(ctx, id, "edited") },
+		"SetTags":      func() (int, error) { return jr.SetTags(ctx, id, []string{"more"}) },
+		"SetEntryDate": func() (int, error) { return jr.SetEntryDate(ctx, id, time.Now().AddDate(0, 0, -1)) },
+		"SetTitle":     func() (int, error) { return jr.SetTitle(ctx, id, "Title") },
+	}
+	for name, edit := range edits {
+		if _, err := edit(); !errors.Is(err, domains.ErrJournalNotFound) {
+			t.Errorf("%s on a trashed journal = %v, want ErrJournalNotFound", name, err)
+		}
+	}
+
+	// Renaming a tag still reaches the trash.
+	if _, err := jr.RenameTag(ctx, "trash", "bin", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jr.Restore(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := jr.Read(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journal.Content != "in the #bin" || journal.Title != "" {
+		t.Errorf("restored journal = %q %q, want it renamed but otherwise unchanged", journal.Title, journal.Content)
+	}
+	revisions, err := jr.ListRevisions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 {
+		t.Errorf("restored journal has %d revisions, want only the rename's", len(revisions))
+	}
+}
+
+func TestReadMissingRevision(t *testing.T) {
+	jr := newRepository(t)
+	if _, err := jr.ReadRevision(context.Background(), 42); !errors.Is(err, domains.ErrRevisionNotFound) {
+		t.Errorf("ReadRevision(42) = %v, want ErrRevisionNotFound", err)
+	}
+}
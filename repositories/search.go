@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+// snippetWords is how many words of context searchSealed shows, like the
+// 16 tokens the full-text snippet uses.
+const snippetWords = 16
+
+type searchTerm struct {
+	text   string
+	negate bool
+}
+
+// searchSealed searches an encrypted journal, which has no full-text index,
+// by opening every entry. It accepts the same "phrases", prefix* and AND, OR
+// and NOT operators, but matches words anywhere in the text and ranks entries
+// by how often the terms appear.
+func (jr *journalRepository) searchSealed(ctx context.Context, query string) ([]domains.SearchResult, error) {
+	groups := parseSearch(query)
+
+	var results []domains.SearchResult
+	err := jr.Each(ctx, func(journal domains.Journal) error {
+		text := strings.ToLower(journal.Title + "\n" + journal.Content)
+		score := 0
+		for _, group := range groups {
+			score = max(score, matchTerms(text, group))
+		}
+		if score > 0 {
+			results = append(results, domains.SearchResult{
+				Journal: journal,
+				Snippet: snippet(journal.Content, groups),
+				Rank:    -float64(score),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("ERROR: failed to search journals: %v", err)
+		return nil, err
+	}
+
+	// Each goes oldest first, so equal matches end up newest first
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank < results[j].Rank
+		}
+		return results[i].Journal.EntryDate.After(results[j].Journal.EntryDate)
+	})
+	return results, nil
+}
+
+// parseSearch splits a query into groups separated by OR. Every term of a
+// group has to match, or not match when it follows NOT. Like ftsQuery, a
+// group that starts with NOT matches nothing and is left out.
+func parseSearch(query string) [][]searchTerm {
+	var groups [][]searchTerm
+	var group []searchTerm
+	negate, excluded := false, false
+	for _, token := range searchTokens(query) {
+		if !token.quoted {
+			switch token.text {
+			case "AND":
+				continue
+			case "OR":
+				if len(group) > 0 && !excluded {
+					groups = append(groups, group)
+				}
+				group, negate, excluded = nil, false, false
+				continue
+			case "NOT":
+				negate = true
+				excluded = excluded || len(group) == 0
+				continue
+			}
+		}
+
+		text := strings.ToLower(strings.TrimSuffix(token.text, "*"))
+		if text != "" {
+			group = append(group, searchTerm{text: text, negate: negate})
+		}
+		negate = false
+	}
+	if len(group) > 0 && !excluded {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// ftsQuery turns what was typed into the search box into an FTS5 query
+// that can't be a syntax error. Every term is quoted, so punctuation like
+// the ' in don't or the - in e-mail is matched as text, keeping a trailing *
+// as a prefix search. AND, OR and NOT are passed through where they join
+// two terms; an operator after another replaces it. FTS5 has no NOT without
+// a term before it, so a group that starts with NOT is dropped up to the
+// next OR, and a query of only that matches nothing.
+func ftsQuery(query string) string {
+	var parts []string
+	operator, excluded := false, false
+	for _, token := range searchTokens(query) {
+		if !token.quoted && (token.text == "AND" || token.text == "OR" || token.text == "NOT") {
+			if token.text == "OR" {
+				excluded = false
+			}
+			switch {
+			case excluded:
+			case token.text == "NOT" && (len(parts) == 0 || parts[len(parts)-1] == "OR"):
+				excluded = true
+			case len(parts) == 0:
+			case operator:
+				parts[len(parts)-1] = token.text
+			default:
+				parts = append(parts, token.text)
+				operator = true
+			}
+			continue
+		}
+		if excluded {
+			continue
+		}
+
+		text, prefix := token.text, false
+		if !token.quoted {
+			text, prefix = strings.CutSuffix(text, "*")
+		}
+		if text == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		parts = append(parts, term)
+		operator = false
+	}
+	if operator {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, " ")
+}
+
+type searchToken struct {
+	text   string
+	quoted bool
+}
+
+// searchTokens splits a query on spaces and parentheses, keeping quoted
+// phrases whole.
+func searchTokens(query string) []searchToken {
+	var tokens []searchToken
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, searchToken{text: word.String()})
+			word.Reset()
+		}
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '"':
+			flush()
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if phrase := strings.Join(strings.Fields(string(runes[i+1:min(end, len(runes))])), " "); phrase != "" {
+				tokens = append(tokens, searchToken{text: phrase, quoted: true})
+			}
+			i = end
+		case unicode.IsSpace(r) || r == '(' || r == ')':
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// matchTerms returns how often the terms of a group appear in text, or 0
+// when the group does not match.
+func matchTerms(text string, group []searchTerm) int {
+	score := 0
+	for _, term := range group {
+		n := strings.Count(text, term.text)
+		if term.negate {
+			if n > 0 {
+				return 0
+			}
+			continue
+		}
+		if n == 0 {
+			return 0
+		}
+		score += n
+	}
+	return score
+}
+
+// snippet shows the words of content around the first match, with every
+// matching word wrapped in the highlight markers.
+func snippet(content string, groups [][]searchTerm) string {
+	var needles []string
+	for _, group := range groups {
+		for _, term := range group {
+			if !term.negate {
+				needles = append(needles, strings.Fields(term.text)...)
+			}
+		}
+	}
+	matches := func(word string) bool {
+		word = strings.ToLower(word)
+		for _, needle := range needles {
+			if strings.Contains(word, needle) {
+				return true
+			}
+		}
+		return false
+	}
+
+	words := strings.Fields(content)
+	first := 0
+	for i, word := range words {
+		if matches(word) {
+			first = i
+			break
+		}
+	}
+	start := max(0, min(first-snippetWords/4, len(words)-snippetWords))
+	end := min(len(words), start+snippetWords)
+
+	shown := make([]string, 0, end-start)
+	for _, word := range words[start:end] {
+		if matches(word) {
+			word = domains.HighlightStart + word + domains.HighlightEnd
+		}
+		shown = append(shown, word)
+	}
+
+	text := strings.Join(shown, " ")
+	if start > 0 {
+		text = "…" + text
+	}
+	if end < len(words) {
+		text += "…"
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  [][]searchTerm
+	}{
+		{"", nil},
+		{"Coffee", [][]searchTerm{{{text: "coffee"}}}},
+		{"coffee AND tea", [][]searchTerm{{{text: "coffee"}, {text: "tea"}}}},
+		{"coffee OR tea", [][]searchTerm{{{text: "coffee"}}, {{text: "tea"}}}},
+		{"coffee NOT tea", [][]searchTerm{{{text: "coffee"}, {text: "tea", negate: true}}}},
+		{`"Morning  run" park`, [][]searchTerm{{{text: "morning run"}, {text: "park"}}}},
+		{"walk*", [][]searchTerm{{{text: "walk"}}}},
+		{`"OR" and`, [][]searchTerm{{{text: "or"}, {text: "and"}}}},
+		{"(coffee OR) OR tea", [][]searchTerm{{{text: "coffee"}}, {{text: "tea"}}}},
+		{`"unterminated phrase`, [][]searchTerm{{{text: "unterminated phrase"}}}},
+		{"NOT rome", nil},
+		{"NOT rome coffee OR tea", [][]searchTerm{{{text: "tea"}}}},
+		{"coffee AND NOT rome", [][]searchTerm{{{text: "coffee"}, {text: "rome", negate: true}}}},
+	}
+	for _, tt := range tests {
+		if got := parseSearch(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSearch(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTerms(t *testing.T) {
+	text := "coffee with milk, then more coffee"
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"coffee", 2},
+		{"coffee milk", 3},
+		{"coffee tea", 0},
+		{"coffee NOT tea", 2},
+		{"coffee NOT milk", 0},
+		{`"with milk"`, 1},
+		{"cof*", 2},
+	}
+	for _, tt := range tests {
+		score := 0
+		for _, group := range parseSearch(tt.query) {
+			score = max(score, matchTerms(text, group))
+		}
+		if score != tt.want {
+			t.Errorf("%q scored %d, want %d", tt.query, score, tt.want)
+		}
+	}
+}
+
+func TestFtsQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"coffee", `"coffee"`},
+		{"don't", `"don't"`},
+		{"e-mail", `"e-mail"`},
+		{"#work", `"#work"`},
+		{"walk*", `"walk"*`},
+		{`"morning run"`, `"morning run"`},
+		{`"unterminated`, `"unterminated"`},
+		{`say "hi"there`, `"say" "hi" "there"`},
+		{"coffee OR tea", `"coffee" OR "tea"`},
+		{"coffee NOT tea", `"coffee" NOT "tea"`},
+		{"AND coffee", `"coffee"`},
+		{"coffee AND", `"coffee"`},
+		{"coffee AND OR tea", `"coffee" OR "tea"`},
+		{"NOT", ""},
+		{"NOT rome", ""},
+		{"NOT rome coffee OR tea", `"tea"`},
+		{"coffee OR NOT rome", `"coffee"`},
+		{"coffee AND NOT rome", `"coffee" NOT "rome"`},
+		{"*", ""},
+		{`"OR"`, `"OR"`},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.query); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cheersmas/jou/domains"
+)
+
+// SetTags replaces the tags set explicitly on a journal. Tags written in its
+// content are kept regardless. Like other edits it fails for a journal in
+// the trash.
+func (jr *journalRepository) SetTags(ctx context.Context, id int, tags []string) (int, error) {
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	journal, err := jr.scanJournal(tx.StmtContext(ctx, jr.readJournalQuery).QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows || err == nil && journal.DeletedAt != nil {
+		return -1, fmt.Errorf("%w: id %d", domains.ErrJournalNotFound, id)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	if _, err := tx.StmtContext(ctx, jr.clearTagsQuery).ExecContext(ctx, id); err != nil {
+		return -1, err
+	}
+	if err := jr.tagJournal(ctx, tx, id, tags, true); err != nil {
+		return -1, err
+	}
+	if err := jr.tagJournal(ctx, tx, id, domains.ExtractTags(journal.Content), false); err != nil {
+		return -1, err
+	}
+	if _, err := tx.StmtContext(ctx, jr.pruneTagsQuery).ExecContext(ctx); err != nil {
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+// ListByTag returns the journals carrying tag, newest first.
+func (jr *journalRepository) ListByTag(ctx context.Context, tag string) ([]domains.Journal, error) {
+	lookup, err := jr.lookup(tag)
+	if err != nil {
+		return nil, err
+	}
+	return jr.queryJournals(ctx, jr.listByTagQuery, lookup)
+}
+
+// ListTags returns every tag in use outside the trash, sorted by name.
+func (jr *journalRepository) ListTags(ctx context.Context) ([]domains.Tag, error) {
+	rows, err := jr.listTagsQuery.QueryContext(ctx)
+	if err != nil {
+		log.Printf("ERROR: failed to query tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	// SQLite loses the column type of MAX(entryDate), so the last use is
+	// worked out here instead.
+	var tags []domains.Tag
+	lastId := -1
+	for rows.Next() {
+		var id int
+		var tag domains.Tag
+		if err := rows.Scan(&id, &tag.Name, &tag.LastUsed); err != nil {
+			log.Printf("ERROR: failed to scan tag row: %v", err)
+			return nil, err
+		}
+		tag.LastUsed = tag.LastUsed.Local()
+
+		n := len(tags)
+		if n > 0 && id == lastId {
+			tags[n-1].Count++
+			if tag.LastUsed.After(tags[n-1].LastUsed) {
+				tags[n-1].LastUsed = tag.LastUsed
+			}
+			continue
+		}
+		if tag.Name, err = jr.open(tag.Name); err != nil {
+			return nil, err
+		}
+		tag.Count = 1
+		tags = append(tags, tag)
+		lastId = id
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("ERROR: error after scanning tag rows: %v", err)
+		return nil, err
+	}
+
+	slices.SortFunc(tags, func(a, b domains.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+// RenameTag renames a tag on every journal in one transaction, rewriting
+// #from as #to in their content. When merge is set the tags are combined and
+// to must already exist, otherwise it must not. It returns how many journals
+// carried the tag.
+func (jr *journalRepository) RenameTag(ctx context.Context, from, to string, merge bool) (int, error) {
+	tx, err := jr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// A tag nothing uses any more should not block the new name.
+	if _, err := tx.StmtContext(ctx, jr.pruneTagsQuery).ExecContext(ctx); err != nil {
+		return -1, err
+	}
+
+	fromId, err := jr.tagId(ctx, tx, from)
+	if err != nil {
+		return -1, err
+	}
+	toId, err := jr.tagId(ctx, tx, to)
+	switch {
+	case err == nil && !merge:
+		return -1, fmt.Errorf("%w: #%s", domains.ErrTagExists, to)
+	case errors.Is(err, domains.ErrTagNotFound) && !merge:
+	case err != nil:
+		return -1, err
+	}
+
+	journals, err := jr.taggedContent(ctx, tx, fromId)
+	if err != nil {
+		return -1, err
+	}
+
+	if merge {
+		if _, err := tx.StmtContext(ctx, jr.moveTagQuery).ExecContext(ctx, toId, fromId); err != nil {
+			log.Printf("ERROR: failed to merge tag %q into %q: %v", from, to, err)
+			return -1, err
+		}
+		if _, err := tx.StmtContext(ctx, jr.deleteTagQuery).ExecContext(ctx, fromId); err != nil {
+			return -1, err
+		}
+	} else {
+		sealed, err := jr.seal(to)
+		if err != nil {
+			return -1, err
+		}
+		lookup, err := jr.lookup(to)
+		if err != nil {
+			return -1, err
+		}
+		if _, err := tx.StmtContext(ctx, jr.renameTagQuery).ExecContext(ctx, sealed, lookup, fromId); err != nil {
+			log.Printf("ERROR: failed to rename tag %q to %q: %v", from, to, err)
+			return -1, err
+		}
+	}
+
+	// Rewriting inline tags is an edit like any other, so it leaves a revision.
+	snapshot := tx.StmtContext(ctx, jr.snapshotRevisionQuery)
+	update := tx.StmtContext(ctx, jr.retagJournalQuery)
+	now := storedTime(time.Now())
+	for id, content := range journals {
+		renamed := domains.RenameInlineTag(content, from, to)
+		if renamed == content {
+			continue
+		}
+		sealed, err := jr.seal(renamed)
+		if err != nil {
+			return -1, err
+		}
+		if _, err := snapshot.ExecContext(ctx, id); err != nil {
+			log.Printf("ERROR: failed to snapshot journal %d: %v", id, err)
+			return -1, err
+		}
+		if _, err := update.ExecContext(ctx, sealed, now, id); err != nil {
+			return -1, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return len(journals), nil
+}
+
+func (jr *journalRepository) tagId(ctx context.Context, tx *sql.Tx, name string) (int, error) {
+	lookup, err := jr.lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	var id int
+	err = tx.StmtContext(ctx, jr.tagIdQuery).QueryRowContext(ctx, lookup).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, fmt.Errorf("%w: #%s", domains.ErrTagNotFound, name)
+	}
+	return id, err
+}
+
+// taggedContent maps the id of every journal carrying a tag to its opened
+// content.
+func (jr *journalRepository) taggedContent(ctx context.Context, tx *sql.Tx, tagId int) (map[int]string, error) {
+	rows, err := tx.StmtContext(ctx, jr.taggedContentQuery).QueryContext(ctx, tagId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	journals := map[int]string{}
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, err
+		}
+		if journals[id], err = jr.open(content); err != nil {
+			return nil, err
+		}
+	}
+	return journals, rows.Err()
+}
+
+// tagJournal links a journal to tags inside tx, creating the tags as needed.
+// Tags are found by their lookup, so an existing tag keeps its sealed name.
+func (jr *journalRepository) tagJournal(ctx context.Context, tx *sql.Tx, id int, tags []string, explicit bool) error {
+	insertTag := tx.StmtContext(ctx, jr.insertTagQuery)
+	tagJournal := tx.StmtContext(ctx, jr.tagJournalQuery)
+	for _, tag := range tags {
+		sealed, err := jr.seal(tag)
+		if err != nil {
+			return err
+		}
+		lookup, err := jr.lookup(tag)
+		if err != nil {
+			return err
+		}
+		if _, err := insertTag.ExecContext(ctx, sealed, lookup); err != nil {
+			log.Printf("ERROR: failed to create tag %q: %v", tag, err)
+			return err
+		}
+		if _, err := tagJournal.ExecContext(ctx, id, explicit, lookup); err != nil {
+			log.Printf("ERROR: failed to tag journal %d: %v", id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// retagInline replaces a journal's inline tags with the ones in content and
+// drops tags no journal uses any more.
+func (jr *journalRepository) retagInline(ctx context.Context, tx *sql.Tx, id int, content string) error {
+	if _, err := tx.StmtContext(ctx, jr.clearInlineTagsQuery).ExecContext(ctx, id); err != nil {
+		return err
+	}
+	if err := jr.tagJournal(ctx, tx, id, domains.ExtractTags(content), false); err != nil {
+		return err
+	}
+	_, err := tx.StmtContext(ctx, jr.pruneTagsQuery).ExecContext(ctx)
+	return err
+}
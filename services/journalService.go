@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/cheersmas/jou/domains"
 	"github.com/cheersmas/jou/ports"
@@ -11,7 +15,20 @@ type journalService struct {
 	journalRepository ports.JournalRepository
 }
 
+// Create saves a new journal, dated now unless it has an entry date. Entries
+// can be backdated but not dated in the future, see domains.ClampEntryDate.
 func (js *journalService) Create(ctx context.Context, content domains.Journal) (int, error) {
+	tags, err := normalizeTags(content.Tags)
+	if err != nil {
+		return -1, err
+	}
+	if !content.EntryDate.IsZero() {
+		if content.EntryDate, err = domains.ClampEntryDate(content.EntryDate, time.Now()); err != nil {
+			return -1, err
+		}
+	}
+	content.Tags = tags
+	content.Title = normalizeTitle(content.Title)
 	return js.journalRepository.Create(ctx, content)
 }
 
@@ -23,6 +40,27 @@ func (js *journalService) Update(ctx context.Context, id int, content string) (i
 	return js.journalRepository.Update(ctx, id, content)
 }
 
+// SetEntryDate moves a journal to another date, under the same rule as
+// Create.
+func (js *journalService) SetEntryDate(ctx context.Context, id int, date time.Time) (int, error) {
+	date, err := domains.ClampEntryDate(date, time.Now())
+	if err != nil {
+		return -1, err
+	}
+	return js.journalRepository.SetEntryDate(ctx, id, date)
+}
+
+// SetTitle gives a journal an explicit title. An empty title goes back to
+// one derived from the content.
+func (js *journalService) SetTitle(ctx context.Context, id int, title string) (int, error) {
+	return js.journalRepository.SetTitle(ctx, id, normalizeTitle(title))
+}
+
+// normalizeTitle keeps titles to a single line.
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
 func (js *journalService) Delete(ctx context.Context, id int) (int, error) {
 	return js.journalRepository.Delete(ctx, id)
 }
@@ -31,6 +69,201 @@ func (js *journalService) ListAll(ctx context.Context) ([]domains.Journal, error
 	return js.journalRepository.ListAll(ctx)
 }
 
+func (js *journalService) Each(ctx context.Context, fn func(domains.Journal) error) error {
+	return js.journalRepository.Each(ctx, fn)
+}
+
+func (js *journalService) ListDeleted(ctx context.Context) ([]domains.Journal, error) {
+	return js.journalRepository.ListDeleted(ctx)
+}
+
+func (js *journalService) Restore(ctx context.Context, id int) (int, error) {
+	return js.journalRepository.Restore(ctx, id)
+}
+
+func (js *journalService) Purge(ctx context.Context, id int) (int, error) {
+	return js.journalRepository.Purge(ctx, id)
+}
+
+// PurgeExpired permanently removes journals that have been in the trash for
+// longer than retention.
+func (js *journalService) PurgeExpired(ctx context.Context, retention time.Duration) (int, error) {
+	return js.journalRepository.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+}
+
+// Search runs an FTS5 query against journal content. Phrases ("in quotes"),
+// prefixes (word*) and the AND, OR and NOT operators are supported; results
+// come back best match first. An encrypted journal has no index, so it is
+// searched by opening every entry instead.
+func (js *journalService) Search(ctx context.Context, query string) ([]domains.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	results, err := js.journalRepository.Search(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("search %q failed: %w", query, err)
+	}
+	return results, nil
+}
+
+// SetTags replaces the tags set explicitly on a journal. A leading # is
+// optional and tags are stored lower-cased; #tags written in the content
+// stay regardless.
+func (js *journalService) SetTags(ctx context.Context, id int, tags []string) (int, error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return -1, err
+	}
+	return js.journalRepository.SetTags(ctx, id, normalized)
+}
+
+func (js *journalService) ListByTag(ctx context.Context, tag string) ([]domains.Journal, error) {
+	normalized, err := domains.NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return js.journalRepository.ListByTag(ctx, normalized)
+}
+
+func (js *journalService) ListTags(ctx context.Context) ([]domains.Tag, error) {
+	return js.journalRepository.ListTags(ctx)
+}
+
+// RenameTag renames a tag on every journal, including #tags written in
+// their content. It fails if the new name is already taken; use MergeTags
+// to combine two tags. It returns how many journals were retagged.
+func (js *journalService) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return js.renameTag(ctx, from, to, false)
+}
+
+// MergeTags moves every journal tagged from over to into, which must exist,
+// and removes from.
+func (js *journalService) MergeTags(ctx context.Context, from, into string) (int, error) {
+	return js.renameTag(ctx, from, into, true)
+}
+
+func (js *journalService) renameTag(ctx context.Context, from, to string, merge bool) (int, error) {
+	from, err := domains.NormalizeTag(from)
+	if err != nil {
+		return -1, err
+	}
+	if to, err = domains.NormalizeTag(to); err != nil {
+		return -1, err
+	}
+	if from == to {
+		return -1, fmt.Errorf("cannot rename #%s to itself", from)
+	}
+	return js.journalRepository.RenameTag(ctx, from, to, merge)
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		name, err := domains.NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
+
+func (js *journalService) ListRevisions(ctx context.Context, journalId int) ([]domains.Revision, error) {
+	return js.journalRepository.ListRevisions(ctx, journalId)
+}
+
+// RestoreRevision brings back an earlier version of a journal. The content
+// being replaced is kept as a revision itself, so a restore can be undone.
+func (js *journalService) RestoreRevision(ctx context.Context, journalId int, revisionId int) (int, error) {
+	revision, err := js.journalRepository.ReadRevision(ctx, revisionId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to read revision %d: %w", revisionId, err)
+	}
+	if revision.JournalId != journalId {
+		return -1, fmt.Errorf("revision %d does not belong to journal %d", revisionId, journalId)
+	}
+	return js.journalRepository.Update(ctx, journalId, revision.Content)
+}
+
+// SaveDraft autosaves text that is still being written and returns the id
+// to save it under next time.
+func (js *journalService) SaveDraft(ctx context.Context, draft domains.Draft) (int, error) {
+	return js.journalRepository.SaveDraft(ctx, draft)
+}
+
+// ListDrafts returns every draft, most recent first: those left behind by
+// editors that were never saved or closed, and those of editors still open.
+func (js *journalService) ListDrafts(ctx context.Context) ([]domains.Draft, error) {
+	return js.journalRepository.ListDrafts(ctx)
+}
+
+// TouchDraft marks a draft as still being written without saving it again.
+func (js *journalService) TouchDraft(ctx context.Context, id int) error {
+	return js.journalRepository.TouchDraft(ctx, id)
+}
+
+func (js *journalService) DiscardDraft(ctx context.Context, id int) error {
+	return js.journalRepository.DeleteDraft(ctx, id)
+}
+
+// PublishDraft saves a draft as the journal it edits, or as a new one, and
+// discards it in the same transaction. It returns the id of the journal.
+func (js *journalService) PublishDraft(ctx context.Context, draft domains.Draft) (int, error) {
+	if !draft.EntryDate.IsZero() {
+		var err error
+		if draft.EntryDate, err = domains.ClampEntryDate(draft.EntryDate, time.Now()); err != nil {
+			return -1, err
+		}
+	}
+	return js.journalRepository.PublishDraft(ctx, draft)
+}
+
+// Encrypted reports whether the journal is sealed with a passphrase.
+func (js *journalService) Encrypted() bool {
+	return js.journalRepository.Encrypted()
+}
+
+// Locked reports whether the journal must be unlocked before it is read.
+func (js *journalService) Locked() bool {
+	return js.journalRepository.Locked()
+}
+
+// Unlock opens an encrypted journal for the rest of the session.
+func (js *journalService) Unlock(ctx context.Context, passphrase string) error {
+	return js.journalRepository.Unlock(ctx, passphrase)
+}
+
+// Lock forgets the key of an encrypted journal until it is unlocked again.
+func (js *journalService) Lock() {
+	js.journalRepository.Lock()
+}
+
+// Encrypt seals every entry with a key derived from passphrase. There is no
+// way to recover the entries without it.
+func (js *journalService) Encrypt(ctx context.Context, passphrase string) error {
+	if passphrase == "" {
+		return domains.ErrEmptyPassphrase
+	}
+	return js.journalRepository.Encrypt(ctx, passphrase)
+}
+
+// Decrypt stores every entry as plaintext again.
+func (js *journalService) Decrypt(ctx context.Context) error {
+	return js.journalRepository.Decrypt(ctx)
+}
+
+// ChangePassphrase re-encrypts every entry under a new passphrase. progress,
+// when not nil, is told how many entries and revisions have been done.
+func (js *journalService) ChangePassphrase(ctx context.Context, passphrase string, progress func(done, total int)) error {
+	if passphrase == "" {
+		return domains.ErrEmptyPassphrase
+	}
+	return js.journalRepository.ChangePassphrase(ctx, passphrase, progress)
+}
+
 func NewJournalService(js ports.JournalRepository) *journalService {
 	return &journalService{
 		journalRepository: js,
@@ -0,0 +1,116 @@
+// Package vault seals journal text with a key derived from a passphrase.
+//
+// Keys are derived with Argon2id and text is sealed with XChaCha20-Poly1305,
+// so tampering with sealed text is detected when it is opened. Sealed text is
+// base64 so it can be stored in the TEXT columns it replaces. Text that has
+// to be looked up, like tag names, is also hashed with HMAC-SHA256 under a
+// second key derived from the first.
+package vault
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ErrWrongKey is returned when sealed text cannot be opened, either because
+// it was sealed with another key or because it was modified.
+var ErrWrongKey = errors.New("wrong key or corrupted data")
+
+// checkText is sealed with every new key so a passphrase can be verified
+// without opening any journal.
+const checkText = "jou"
+
+// Params are the Argon2id settings a key is derived with. They are stored
+// next to the sealed data, so they can be raised later without breaking
+// existing journals.
+type Params struct {
+	Salt    []byte
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+}
+
+// NewParams returns the current recommended settings with a fresh salt.
+func NewParams() (Params, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Params{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return Params{Salt: salt, Time: 3, Memory: 64 * 1024, Threads: 4}, nil
+}
+
+// Key seals and opens text. It only lives in memory.
+type Key struct {
+	aead cipher.AEAD
+	hash []byte
+}
+
+// DeriveKey stretches passphrase into a key. It is deliberately slow.
+func DeriveKey(passphrase string, params Params) (*Key, error) {
+	if len(params.Salt) == 0 || params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+	secret := argon2.IDKey([]byte(passphrase), params.Salt, params.Time, params.Memory, params.Threads, chacha20poly1305.KeySize)
+	aead, err := chacha20poly1305.NewX(secret)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("jou lookup"))
+	return &Key{aead: aead, hash: mac.Sum(nil)}, nil
+}
+
+// Hash returns the same hex string for the same text and key, so sealed
+// text can still be found by what it says.
+func (k *Key) Hash(text string) string {
+	mac := hmac.New(sha256.New, k.hash)
+	mac.Write([]byte(text))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Seal encrypts text under a random nonce, so sealing the same text twice
+// gives different results.
+func (k *Key) Seal(text string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(text)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(text), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts text sealed by Seal with the same key.
+func (k *Key) Open(sealed string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(b) < k.aead.NonceSize() {
+		return "", ErrWrongKey
+	}
+	text, err := k.aead.Open(nil, b[:k.aead.NonceSize()], b[k.aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	return string(text), nil
+}
+
+// Check seals a known text to store alongside the params.
+func (k *Key) Check() (string, error) {
+	return k.Seal(checkText)
+}
+
+// Verify reports whether check was made by Check with this key.
+func (k *Key) Verify(check string) error {
+	text, err := k.Open(check)
+	if err != nil || text != checkText {
+		return ErrWrongKey
+	}
+	return nil
+}